@@ -1,17 +1,27 @@
 package main
 
 import (
+	"bot/internal/afk"
+	"bot/internal/chatlog"
+	"bot/internal/commands"
 	"bot/internal/database"
 	"bot/internal/handler"
 	"bot/internal/helix"
 	httpclient "bot/internal/http"
+	"bot/internal/importer"
 	"bot/internal/models"
+	"bot/internal/queue"
+	"bot/internal/reminders"
+	"bot/internal/timers"
 	"bot/internal/utils"
 	"bot/web"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
@@ -36,12 +46,31 @@ func main() {
 		log.Fatalf("Could not read and parse config file: %s", err)
 	}
 
+	if config.WebSecret == "" {
+		log.Println("No web_secret in config file, generating one. Search links will stop working when the bot restarts")
+		secret := make([]byte, 32)
+		rand.Read(secret)
+		config.WebSecret = hex.EncodeToString(secret)
+	}
+
 	log.Println("Creating PostgreSQL pool")
 	db, err := loadDB(config.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Could not load DB: %s", err)
 	}
 
+	// bot import <channel> <file>
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if len(os.Args) != 4 {
+			log.Fatalf("Usage: %s import <channel> <file>", os.Args[0])
+		}
+		err = importFile(db, os.Args[2], os.Args[3])
+		if err != nil {
+			log.Fatalf("Could not import commands: %s", err)
+		}
+		return
+	}
+
 	log.Println("Creating HTTP client")
 	httpClient := httpclient.Client{
 		Client: &http.Client{},
@@ -70,6 +99,14 @@ func main() {
 		log.Printf("Helix token valid, expires in %s\n", utils.PrettyDuration(expires))
 	}
 
+	botUserID, found, err := helix.LoginToID(httpClient, strings.ToLower(config.Identity.BotUsername))
+	if err != nil {
+		log.Fatalf("Could not get ID of bot user: %s", err)
+	}
+	if !found {
+		log.Fatalf("Bot user %s not found", config.Identity.BotUsername)
+	}
+
 	ircClient := irc.NewClient(
 		config.Identity.BotUsername,
 		fmt.Sprintf("oauth:%s", config.Identity.HelixToken),
@@ -129,17 +166,33 @@ func main() {
 		DB:        db,
 		Http:      httpClient,
 		IRC:       ircClient,
+		Queue:     queue.New(ircClient),
 		StartedAt: startedAt,
+		BotUserID: botUserID,
 		TwitchWH:  whClient,
 	}
 
 	ircClient.OnPrivateMessage(handler.OnMessage(&state))
+	ircClient.OnUserStateMessage(handler.OnUserState(&state))
+	ircClient.OnWhisperMessage(handler.OnWhisper(&state))
+	ircClient.OnUserNoticeMessage(handler.OnUserNotice(&state))
+	ircClient.OnClearChatMessage(handler.OnClearChat(&state))
+	ircClient.OnClearMessage(handler.OnClearMessage(&state))
 	ircClient.OnConnect(func() { log.Println("Connected to chat") })
+	go state.Queue.Run()
+	if err := reminders.Load(&state); err != nil {
+		log.Fatalf("Could not load reminders: %s", err)
+	}
+	if err := afk.Load(&state); err != nil {
+		log.Fatalf("Could not load AFK users: %s", err)
+	}
+	go timers.Run(&state)
+	go chatlog.Run(&state)
 
 	whClient.On("stream.online", handler.OnLive(&state))
 
 	log.Println("Starting web server")
-	router, err := web.New(config.BindAddr)
+	router, err := web.New(&state)
 	if err != nil {
 		log.Fatalf("Could not create web server: %s", err)
 	}
@@ -177,6 +230,33 @@ func loadDB(connString string) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+func importFile(db *pgxpool.Pool, channel string, path string) error {
+	chat, found, err := database.GetChatByName(db, strings.ToLower(channel))
+	if err != nil {
+		return fmt.Errorf("Could not get chat: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("Chat %s not found in database", channel)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read file: %w", err)
+	}
+	result, err := importer.Parse(data)
+	if err != nil {
+		return fmt.Errorf("Could not parse file: %w", err)
+	}
+	imported, skipped, err := commands.ImportEntries(db, chat.ChatID, "", result)
+	if err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		log.Printf("Skipped %s: %s", s.Name, s.Reason)
+	}
+	log.Printf("Imported %d command(s) to %s, skipped %d", imported, chat.ChatName, len(skipped))
+	return nil
+}
+
 func loadSubscriptions(s *models.State) error {
 	subs, err := database.GetSubscriptions(s.DB)
 	if err != nil {
@@ -0,0 +1,135 @@
+// Package afk keeps track of users who are away, and builds the messages announcing their return.
+// Statuses are stored in the database, and served from memory since they are checked on every message.
+package afk
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Users are only mentioned as away once per chat within this window, so a conversation doesn't trigger a notice every message.
+const NoticeCooldown = time.Minute
+
+type noticeKey struct {
+	chatID int
+	userID int
+}
+
+var (
+	mu sync.Mutex
+	// AFK users by user ID
+	statuses = make(map[int]models.AFK)
+	// User IDs of AFK users by login, used to find mentioned users
+	logins = make(map[string]int)
+	// Last time a mentioner was told a user is away, by chat and AFK user
+	noticed = make(map[noticeKey]time.Time)
+)
+
+// Load loads the AFK users from the database. Must be called once on startup.
+func Load(state *models.State) error {
+	afks, err := database.GetAFKs(state.DB)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, a := range afks {
+		statuses[a.UserID] = a
+		logins[a.Login] = a.UserID
+	}
+	return nil
+}
+
+// Set marks a user as AFK.
+func Set(state *models.State, status models.AFK) error {
+	if err := database.SetAFK(state.DB, status); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if old, found := statuses[status.UserID]; found {
+		delete(logins, old.Login)
+	}
+	statuses[status.UserID] = status
+	logins[status.Login] = status.UserID
+	return nil
+}
+
+// Return clears the AFK status of a user. Returns the status and true if the user was AFK.
+func Return(state *models.State, userID int) (models.AFK, bool, error) {
+	mu.Lock()
+	status, found := statuses[userID]
+	if found {
+		delete(statuses, userID)
+		delete(logins, status.Login)
+		for key := range noticed {
+			if key.userID == userID {
+				delete(noticed, key)
+			}
+		}
+	}
+	mu.Unlock()
+	if !found {
+		return status, false, nil
+	}
+	return status, true, database.DeleteAFK(state.DB, userID)
+}
+
+// Mentioned returns the AFK users @mentioned in a message, leaving out users that were already
+// noticed in the chat within [NoticeCooldown].
+func Mentioned(chatID int, text string) []models.AFK {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(statuses) == 0 {
+		return nil
+	}
+	var mentioned []models.AFK
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, "@") {
+			continue
+		}
+		login := strings.ToLower(strings.TrimRight(strings.TrimPrefix(word, "@"), ",.:;!?"))
+		userID, found := logins[login]
+		if !found {
+			continue
+		}
+		key := noticeKey{chatID, userID}
+		if time.Since(noticed[key]) < NoticeCooldown {
+			continue
+		}
+		noticed[key] = time.Now()
+		mentioned = append(mentioned, statuses[userID])
+	}
+	return mentioned
+}
+
+// ReturnMessage announces that a user is back, like "linneb is back after 8 hours of sleep: zzz".
+func ReturnMessage(status models.AFK, now time.Time) string {
+	away := utils.PrettyDuration(now.Sub(status.Since))
+	message := fmt.Sprintf("%s is no longer AFK after %s", status.Login, away)
+	if status.Kind == models.AFKSleep {
+		message = fmt.Sprintf("%s woke up after %s of sleep", status.Login, away)
+	}
+	if status.Reason != "" {
+		message += ": " + status.Reason
+	}
+	return message
+}
+
+// NoticeMessage tells a mentioner that a user is away, like "linneb is AFK (20 minutes ago): lunch".
+func NoticeMessage(status models.AFK, now time.Time) string {
+	state := "AFK"
+	if status.Kind == models.AFKSleep {
+		state = "sleeping"
+	}
+	message := fmt.Sprintf("%s is %s (%s ago)", status.Login, state, utils.PrettyDuration(now.Sub(status.Since)))
+	if status.Reason != "" {
+		message += ": " + status.Reason
+	}
+	return message
+}
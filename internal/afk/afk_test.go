@@ -0,0 +1,57 @@
+package afk
+
+import (
+	"bot/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMentioned(t *testing.T) {
+	statuses[1] = models.AFK{UserID: 1, Login: "forsen", Kind: models.AFKAway}
+	logins["forsen"] = 1
+	defer func() {
+		delete(statuses, 1)
+		delete(logins, "forsen")
+	}()
+
+	if mentioned := Mentioned(10, "forsen is not mentioned"); len(mentioned) != 0 {
+		t.Errorf("Expected no mentions without @; Got %v", mentioned)
+	}
+	if mentioned := Mentioned(10, "hey @Forsen, are you there?"); len(mentioned) != 1 || mentioned[0].UserID != 1 {
+		t.Errorf("Expected forsen to be mentioned; Got %v", mentioned)
+	}
+	if mentioned := Mentioned(10, "@forsen hello?"); len(mentioned) != 0 {
+		t.Errorf("Expected no notice within cooldown; Got %v", mentioned)
+	}
+	if mentioned := Mentioned(20, "@forsen"); len(mentioned) != 1 {
+		t.Errorf("Expected notice in another chat; Got %v", mentioned)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		status   models.AFK
+		returned string
+		notice   string
+	}{
+		{
+			models.AFK{Login: "linneb", Kind: models.AFKAway, Reason: "lunch", Since: now.Add(-20 * time.Minute)},
+			"linneb is no longer AFK after 20 minutes: lunch",
+			"linneb is AFK (20 minutes ago): lunch",
+		},
+		{
+			models.AFK{Login: "linneb", Kind: models.AFKSleep, Since: now.Add(-8 * time.Hour)},
+			"linneb woke up after 8 hours of sleep",
+			"linneb is sleeping (8 hours ago)",
+		},
+	}
+	for _, test := range tests {
+		if actual := ReturnMessage(test.status, now); actual != test.returned {
+			t.Errorf("Expected %q; Got %q", test.returned, actual)
+		}
+		if actual := NoticeMessage(test.status, now); actual != test.notice {
+			t.Errorf("Expected %q; Got %q", test.notice, actual)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package chatlog stores the chat messages of chats that opted in to logging.
+// Messages are queued in memory and inserted in batches, so logging never blocks message handling.
+package chatlog
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+const (
+	// Messages queued before new messages are dropped
+	bufferSize = 10000
+	// Messages inserted at once
+	batchSize = 500
+	// Longest time a message waits in the queue before it is inserted
+	flushInterval = 2 * time.Second
+	// How often old partitions are dropped
+	retentionInterval = time.Hour
+)
+
+var (
+	entries = make(chan models.ChatLogEntry, bufferSize)
+	// Number of messages dropped because the queue was full
+	dropped atomic.Int64
+)
+
+// Log queues an entry to be inserted. The entry is dropped if the queue is full.
+func Log(entry models.ChatLogEntry) {
+	select {
+	case entries <- entry:
+	default:
+		if dropped.Add(1)%1000 == 1 {
+			log.Printf("Chat log queue is full, %d message(s) dropped so far", dropped.Load())
+		}
+	}
+}
+
+// Run inserts queued entries and applies the retention of each chat. Blocks forever, so it should be started in a goroutine.
+func Run(state *models.State) {
+	// Partitions known to exist, by chat ID and day
+	partitions := make(map[int]map[time.Time]bool)
+	batch := make([]models.ChatLogEntry, 0, batchSize)
+	flush := time.NewTicker(flushInterval)
+	retention := time.NewTicker(retentionInterval)
+	for {
+		select {
+		case entry := <-entries:
+			batch = append(batch, entry)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-flush.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-retention.C:
+			applyRetention(state)
+			// Dropped partitions have to be created again if an old message shows up
+			clear(partitions)
+			continue
+		}
+		if err := write(state, batch, partitions); err != nil {
+			log.Printf("Could not write %d chat log message(s): %s", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+}
+
+func write(state *models.State, batch []models.ChatLogEntry, partitions map[int]map[time.Time]bool) error {
+	for _, entry := range batch {
+		day := entry.SentAt.UTC().Truncate(24 * time.Hour)
+		if partitions[entry.ChatID][day] {
+			continue
+		}
+		if err := database.EnsureChatLogPartition(state.DB, entry.ChatID, day); err != nil {
+			return fmt.Errorf("Could not create partition: %w", err)
+		}
+		if partitions[entry.ChatID] == nil {
+			partitions[entry.ChatID] = make(map[time.Time]bool)
+		}
+		partitions[entry.ChatID][day] = true
+	}
+	return database.InsertChatLogs(state.DB, batch)
+}
+
+// Drops partitions older than the retention of each chat.
+func applyRetention(state *models.State) {
+	chats, err := database.GetChats(state.DB)
+	if err != nil {
+		log.Printf("Could not get chats: %s", err)
+		return
+	}
+	for _, chat := range chats {
+		cutoff := time.Now().AddDate(0, 0, -chat.LogRetention)
+		n, err := database.DropChatLogsBefore(state.DB, chat.ChatID, cutoff)
+		if err != nil {
+			log.Printf("Could not drop old chat logs of %s: %s", chat.ChatName, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Dropped %d day(s) of chat logs in %s", n, chat.ChatName)
+		}
+	}
+}
+
+// FromPrivateMessage converts a chat message to a log entry.
+func FromPrivateMessage(msg irc.PrivateMessage) (models.ChatLogEntry, error) {
+	chatID, err := strconv.Atoi(msg.RoomID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	userID, err := strconv.Atoi(msg.User.ID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	return models.ChatLogEntry{
+		ChatID:      chatID,
+		SentAt:      msg.Time,
+		Kind:        models.LogPrivmsg,
+		MessageID:   msg.ID,
+		UserID:      userID,
+		Login:       msg.User.Name,
+		DisplayName: msg.User.DisplayName,
+		Text:        msg.Message,
+		Raw:         msg.Raw,
+	}, nil
+}
+
+// FromUserNotice converts a subscription, raid or other user notice to a log entry.
+// The text is the system message, followed by the message of the user if there is one.
+func FromUserNotice(msg irc.UserNoticeMessage) (models.ChatLogEntry, error) {
+	chatID, err := strconv.Atoi(msg.RoomID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	userID, err := strconv.Atoi(msg.User.ID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	text := msg.SystemMsg
+	if msg.Message != "" {
+		text += " " + msg.Message
+	}
+	return models.ChatLogEntry{
+		ChatID:      chatID,
+		SentAt:      msg.Time,
+		Kind:        models.LogUserNotice,
+		MessageID:   msg.ID,
+		UserID:      userID,
+		Login:       msg.User.Name,
+		DisplayName: msg.User.DisplayName,
+		Text:        text,
+		Raw:         msg.Raw,
+	}, nil
+}
+
+// FromClearChat converts a ban, timeout or chat clear to a log entry.
+func FromClearChat(msg irc.ClearChatMessage) (models.ChatLogEntry, error) {
+	chatID, err := strconv.Atoi(msg.RoomID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	entry := models.ChatLogEntry{
+		ChatID: chatID,
+		SentAt: msg.Time,
+		Kind:   models.LogClearChat,
+		Login:  msg.TargetUsername,
+		Text:   "Chat has been cleared",
+		Raw:    msg.Raw,
+	}
+	if msg.TargetUserID == "" {
+		return entry, nil
+	}
+	entry.UserID, err = strconv.Atoi(msg.TargetUserID)
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	if msg.BanDuration > 0 {
+		entry.Text = fmt.Sprintf("%s has been timed out for %s", msg.TargetUsername, utils.PrettyDuration(time.Duration(msg.BanDuration)*time.Second))
+	} else {
+		entry.Text = fmt.Sprintf("%s has been banned", msg.TargetUsername)
+	}
+	return entry, nil
+}
+
+// FromClearMessage converts a deleted message to a log entry.
+func FromClearMessage(msg irc.ClearMessage) (models.ChatLogEntry, error) {
+	// The library does not parse the room ID and timestamp of CLEARMSG
+	chatID, err := strconv.Atoi(msg.Tags["room-id"])
+	if err != nil {
+		return models.ChatLogEntry{}, err
+	}
+	sentAt := time.Now()
+	if ms, err := strconv.ParseInt(msg.Tags["tmi-sent-ts"], 10, 64); err == nil {
+		sentAt = time.UnixMilli(ms)
+	}
+	return models.ChatLogEntry{
+		ChatID:    chatID,
+		SentAt:    sentAt,
+		Kind:      models.LogClearMsg,
+		MessageID: msg.TargetMsgID,
+		Login:     msg.Login,
+		Text:      fmt.Sprintf("A message from %s has been deleted: %s", msg.Login, msg.Message),
+		Raw:       msg.Raw,
+	}, nil
+}
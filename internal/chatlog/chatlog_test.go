@@ -0,0 +1,148 @@
+package chatlog
+
+import (
+	"bot/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+func TestFromPrivateMessage(t *testing.T) {
+	raw := "@badges=;color=;display-name=LinneB;emotes=;id=abc-123;room-id=11148817;tmi-sent-ts=1700000000000;user-id=215185844 :linneb!linneb@linneb.tmi.twitch.tv PRIVMSG #pajlada :hello chat"
+	msg := irc.ParseMessage(raw).(*irc.PrivateMessage)
+	entry, err := FromPrivateMessage(*msg)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if entry.ChatID != 11148817 || entry.UserID != 215185844 || entry.Login != "linneb" || entry.DisplayName != "LinneB" {
+		t.Errorf("Expected chat 11148817 and user linneb (215185844); Got %+v", entry)
+	}
+	if entry.Kind != models.LogPrivmsg || entry.MessageID != "abc-123" || entry.Text != "hello chat" || entry.Raw != raw {
+		t.Errorf("Expected PRIVMSG abc-123 with text and raw line; Got %+v", entry)
+	}
+	if entry.SentAt.UnixMilli() != 1700000000000 {
+		t.Errorf("Expected sent at 1700000000000; Got %d", entry.SentAt.UnixMilli())
+	}
+}
+
+func TestFromClearChat(t *testing.T) {
+	tests := []struct {
+		raw      string
+		userID   int
+		expected string
+	}{
+		{"@ban-duration=600;room-id=11148817;target-user-id=123;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARCHAT #pajlada :forsen", 123, "forsen has been timed out for 10 minutes"},
+		{"@room-id=11148817;target-user-id=123;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARCHAT #pajlada :forsen", 123, "forsen has been banned"},
+		{"@room-id=11148817;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARCHAT #pajlada", 0, "Chat has been cleared"},
+	}
+	for _, test := range tests {
+		msg := irc.ParseMessage(test.raw).(*irc.ClearChatMessage)
+		entry, err := FromClearChat(*msg)
+		if err != nil {
+			t.Errorf("Input %q: Expected no error; Got %s", test.raw, err)
+			continue
+		}
+		if entry.UserID != test.userID || entry.Text != test.expected {
+			t.Errorf("Input %q: Expected user %d and text %q; Got %d and %q", test.raw, test.userID, test.expected, entry.UserID, entry.Text)
+		}
+	}
+}
+
+func TestFromClearMessage(t *testing.T) {
+	raw := "@login=forsen;room-id=11148817;target-msg-id=abc-123;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARMSG #pajlada :bad words"
+	msg := irc.ParseMessage(raw).(*irc.ClearMessage)
+	entry, err := FromClearMessage(*msg)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if entry.ChatID != 11148817 || entry.MessageID != "abc-123" || entry.Login != "forsen" || entry.SentAt.UnixMilli() != 1700000000000 {
+		t.Errorf("Expected deleted message abc-123 from forsen; Got %+v", entry)
+	}
+}
+
+func TestFormatLine(t *testing.T) {
+	sentAt := time.Date(2024, 1, 31, 12, 0, 5, 0, time.UTC)
+	tests := []struct {
+		entry    models.ChatLogEntry
+		expected string
+	}{
+		{models.ChatLogEntry{SentAt: sentAt, Kind: models.LogPrivmsg, Login: "linneb", Text: "hello"}, "[2024-01-31 12:00:05] #forsen linneb: hello"},
+		{models.ChatLogEntry{SentAt: sentAt, Kind: models.LogClearChat, Login: "linneb", Text: "linneb has been banned"}, "[2024-01-31 12:00:05] #forsen linneb has been banned"},
+	}
+	for _, test := range tests {
+		if actual := FormatLine("forsen", test.entry); actual != test.expected {
+			t.Errorf("Expected %q; Got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestToMessage(t *testing.T) {
+	raw := `@display-name=LinneB;id=abc-123;msg-id=resub;room-id=11148817;system-msg=LinneB\ssubscribed\sfor\s2\smonths!;tmi-sent-ts=1700000000000;user-id=215185844;login=linneb :tmi.twitch.tv USERNOTICE #pajlada :hi`
+	msg := irc.ParseMessage(raw).(*irc.UserNoticeMessage)
+	entry, err := FromUserNotice(*msg)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	m := ToMessage("pajlada", entry)
+	if m.Type != irc.USERNOTICE || m.Text != "hi" || m.SystemText != "LinneB subscribed for 2 months!" {
+		t.Errorf("Expected USERNOTICE with text and system text; Got %+v", m)
+	}
+	if m.Tags["msg-id"] != "resub" || m.Channel != "pajlada" || m.ID != "abc-123" {
+		t.Errorf("Expected tags, channel and ID to be set; Got %+v", m)
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	token := SignToken("secret", 123, time.Now().Add(time.Hour))
+	if !VerifyToken("secret", token, 123) {
+		t.Errorf("Expected valid token to verify")
+	}
+	if VerifyToken("secret", token, 456) {
+		t.Errorf("Expected token for another chat to fail")
+	}
+	if VerifyToken("other secret", token, 123) {
+		t.Errorf("Expected token signed with another secret to fail")
+	}
+	if VerifyToken("secret", SignToken("secret", 123, time.Now().Add(-time.Second)), 123) {
+		t.Errorf("Expected expired token to fail")
+	}
+	forged := strings.Replace(token, "123.", "456.", 1)
+	if VerifyToken("secret", forged, 456) {
+		t.Errorf("Expected token with modified chat ID to fail")
+	}
+	if VerifyToken("secret", "garbage", 123) {
+		t.Errorf("Expected malformed token to fail")
+	}
+}
+
+func TestOptedOut(t *testing.T) {
+	optOutsMu.Lock()
+	optOutIDs = map[int]bool{123: true}
+	optOutLogins = map[string]bool{"forsen": true}
+	optOutsMu.Unlock()
+	defer InvalidateOptOuts()
+
+	tests := []struct {
+		userID   int
+		login    string
+		expected bool
+	}{
+		{123, "renamed", true},
+		// CLEARMSG entries have no user ID
+		{0, "forsen", true},
+		{456, "linneb", false},
+	}
+	for _, test := range tests {
+		actual, err := OptedOut(nil, test.userID, test.login)
+		if err != nil || actual != test.expected {
+			t.Errorf("User %d (%s): Expected %t; Got %t, %v", test.userID, test.login, test.expected, actual, err)
+		}
+	}
+
+	InvalidateOptOuts()
+	if optOutIDs != nil || optOutLogins != nil {
+		t.Errorf("Expected opt-outs to be cleared")
+	}
+}
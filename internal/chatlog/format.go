@@ -0,0 +1,69 @@
+package chatlog
+
+import (
+	"bot/internal/models"
+	"fmt"
+	"time"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+// Layout of timestamps in text logs, the same as justlog.
+const lineTimeLayout = "2006-01-02 15:04:05"
+
+// Message in the JSON format of justlog.
+type Message struct {
+	Text        string            `json:"text"`
+	SystemText  string            `json:"systemText"`
+	Username    string            `json:"username"`
+	DisplayName string            `json:"displayName"`
+	Channel     string            `json:"channel"`
+	Timestamp   time.Time         `json:"timestamp"`
+	ID          string            `json:"id"`
+	Type        irc.MessageType   `json:"type"`
+	Raw         string            `json:"raw"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// FormatLine formats an entry as a line of justlog text logs, like "[2024-01-31 12:00:00] #forsen linneb: hello".
+func FormatLine(channel string, entry models.ChatLogEntry) string {
+	timestamp := entry.SentAt.UTC().Format(lineTimeLayout)
+	if entry.Kind == models.LogPrivmsg {
+		return fmt.Sprintf("[%s] #%s %s: %s", timestamp, channel, entry.Login, entry.Text)
+	}
+	return fmt.Sprintf("[%s] #%s %s", timestamp, channel, entry.Text)
+}
+
+// ToMessage converts an entry to the JSON format of justlog. Tags are parsed from the raw line.
+func ToMessage(channel string, entry models.ChatLogEntry) Message {
+	m := Message{
+		Text:        entry.Text,
+		Username:    entry.Login,
+		DisplayName: entry.DisplayName,
+		Channel:     channel,
+		Timestamp:   entry.SentAt.UTC(),
+		ID:          entry.MessageID,
+		Raw:         entry.Raw,
+		Tags:        map[string]string{},
+	}
+	switch parsed := irc.ParseMessage(entry.Raw).(type) {
+	case *irc.PrivateMessage:
+		m.Type = irc.PRIVMSG
+		m.Tags = parsed.Tags
+	case *irc.UserNoticeMessage:
+		m.Type = irc.USERNOTICE
+		m.Tags = parsed.Tags
+		m.Text = parsed.Message
+		m.SystemText = parsed.SystemMsg
+	case *irc.ClearChatMessage:
+		m.Type = irc.CLEARCHAT
+		m.Tags = parsed.Tags
+		m.SystemText = entry.Text
+	case *irc.ClearMessage:
+		m.Type = irc.CLEARMSG
+		m.Tags = parsed.Tags
+		m.Text = parsed.Message
+		m.SystemText = entry.Text
+	}
+	return m
+}
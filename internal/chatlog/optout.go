@@ -0,0 +1,41 @@
+package chatlog
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"sync"
+)
+
+var (
+	optOutsMu sync.Mutex
+	// User IDs and logins of users who opted out, nil until loaded
+	optOutIDs    map[int]bool
+	optOutLogins map[string]bool
+)
+
+// OptedOut returns true if the user opted out of chat logging. CLEARMSG entries have no user ID, so the login is checked too.
+func OptedOut(state *models.State, userID int, login string) (bool, error) {
+	optOutsMu.Lock()
+	defer optOutsMu.Unlock()
+	if optOutIDs == nil {
+		optouts, err := database.GetLogOptOuts(state.DB)
+		if err != nil {
+			return false, err
+		}
+		optOutIDs = make(map[int]bool)
+		optOutLogins = make(map[string]bool)
+		for _, o := range optouts {
+			optOutIDs[o.UserID] = true
+			optOutLogins[o.Login] = true
+		}
+	}
+	return optOutIDs[userID] || optOutLogins[login], nil
+}
+
+// InvalidateOptOuts clears the cached opt-outs. Must be called after a user opts out or in.
+func InvalidateOptOuts() {
+	optOutsMu.Lock()
+	defer optOutsMu.Unlock()
+	optOutIDs = nil
+	optOutLogins = nil
+}
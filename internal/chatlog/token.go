@@ -0,0 +1,43 @@
+package chatlog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long search links posted in chat stay valid.
+const TokenDuration = 24 * time.Hour
+
+// SignToken creates a token granting access to the chat log search of a chat until it expires.
+// Tokens look like "<chat ID>.<expiry unix time>.<signature>".
+func SignToken(secret string, chatID int, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d", chatID, expires.Unix())
+	return payload + "." + signature(secret, payload)
+}
+
+// VerifyToken returns true if token was signed with secret for the chat, and has not expired.
+func VerifyToken(secret string, token string, chatID int) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != strconv.Itoa(chatID) {
+		return false
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signature(secret, parts[0]+"."+parts[1]))) {
+		return false
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(time.Unix(expiry, 0))
+}
+
+func signature(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
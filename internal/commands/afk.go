@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bot/internal/afk"
+	"bot/internal/models"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Longest AFK reason, limited by the database
+const maxAFKReason = 400
+
+var afkCmd = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		reason := ctx.Args.String("reason")
+		if utf8.RuneCountInString(reason) > maxAFKReason {
+			return fmt.Sprintf("Reason is too long (max %d characters).", maxAFKReason), nil
+		}
+		status := models.AFK{
+			UserID: ctx.SenderUserID,
+			Login:  ctx.SenderUsername,
+			Kind:   models.AFKAway,
+			Reason: reason,
+			Since:  time.Now(),
+		}
+		if ctx.Invocation == "sleep" || ctx.Invocation == "gn" {
+			status.Kind = models.AFKSleep
+		}
+		err = afk.Set(state, status)
+		if err != nil {
+			return "", fmt.Errorf("Could not set AFK: %w", err)
+		}
+		if status.Kind == models.AFKSleep {
+			return fmt.Sprintf("%s is now sleeping, good night!", ctx.SenderDisplayname), nil
+		}
+		return fmt.Sprintf("%s is now AFK.", ctx.SenderDisplayname), nil
+	},
+	Metadata: metadata{
+		Name:                "afk",
+		Description:         "Marks you as away until you chat again. Use sleep or gn when going to bed.",
+		ExtendedDescription: "The next time you chat in any chat the bot is in, it announces that you are back, how long you were gone and your reason. If the broadcaster enabled AFK notices with the settings command, people who @mention you while you are away are told that you are away.",
+		Cooldown:            5 * time.Second,
+		MinimumRole:         RGeneric,
+		Aliases:             []string{"afk", "sleep", "gn"},
+		Args:                []argument{{Name: "reason", Type: AText, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Go AFK with a reason:",
+				Command:     "#afk lunch",
+				Response:    "@linneb, linneb is now AFK.",
+			},
+			{
+				Description: "Go to bed:",
+				Command:     "#gn",
+				Response:    "@linneb, linneb is now sleeping, good night!",
+			},
+			{
+				Description: "When you chat again, your return is announced:",
+				Command:     "I'm back",
+				Response:    "linneb is no longer AFK after 20 minutes: lunch",
+			},
+		},
+	},
+}
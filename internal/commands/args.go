@@ -0,0 +1,348 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Argument types, used by [argument] and [flag] declarations.
+const (
+	// A single word.
+	AString = iota + 1
+	// The rest of the message. Must be the last argument.
+	AText
+	// A Twitch login. Lowercased with any leading @ removed.
+	AUser
+	AInt
+	// Go duration syntax, like "30s" or "1h30m".
+	ADuration
+	// One of [argument.Choices].
+	AEnum
+	// Only valid for flags. Set by just passing the flag, like "--live".
+	ABool
+	// A single word, or multiple words wrapped in double quotes.
+	AQuoted
+)
+
+var loginRegex = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+// Positional argument declaration.
+type argument struct {
+	Name     string
+	Type     int
+	Optional bool
+	// Valid values for AEnum arguments.
+	Choices []string
+	// Only valid for AUser arguments. When the command is a reply and the argument is not passed,
+	// it defaults to the author of the message being replied to, and the remaining words are read
+	// as the following arguments.
+	FromReply bool
+}
+
+// Flag declaration. Flags are passed as "--name value" anywhere in the message,
+// or just "--name" for ABool flags.
+type flag struct {
+	Name        string
+	Type        int
+	Description string
+	// Valid values for AEnum flags.
+	Choices []string
+}
+
+type subcommand struct {
+	Name        string
+	Description string
+	Args        []argument
+	Flags       []flag
+}
+
+// Parsed arguments, flags and subcommand of a command invocation.
+type arguments struct {
+	Subcommand string
+	values     map[string]any
+}
+
+// Returns true if the argument or flag was passed.
+func (a arguments) Has(name string) bool {
+	_, found := a.values[name]
+	return found
+}
+
+// Returns the value of an AString, AText, AQuoted, AUser or AEnum argument, or "" if not passed.
+func (a arguments) String(name string) string {
+	s, _ := a.values[name].(string)
+	return s
+}
+
+func (a arguments) Int(name string) int {
+	i, _ := a.values[name].(int)
+	return i
+}
+
+func (a arguments) Duration(name string) time.Duration {
+	d, _ := a.values[name].(time.Duration)
+	return d
+}
+
+func (a arguments) Bool(name string) bool {
+	b, _ := a.values[name].(bool)
+	return b
+}
+
+func (a argument) usage() string {
+	name := a.Name
+	if a.Type == AEnum {
+		name = strings.Join(a.Choices, "|")
+	}
+	if a.Type == AText {
+		name += "..."
+	}
+	if a.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+func (f flag) usage() string {
+	switch f.Type {
+	case ABool:
+		return fmt.Sprintf("[--%s]", f.Name)
+	case AEnum:
+		return fmt.Sprintf("[--%s %s]", f.Name, strings.Join(f.Choices, "|"))
+	}
+	return fmt.Sprintf("[--%s <%s>]", f.Name, f.Name)
+}
+
+func usageOf(args []argument, flags []flag) string {
+	var parts []string
+	for _, a := range args {
+		parts = append(parts, a.usage())
+	}
+	for _, f := range flags {
+		parts = append(parts, f.usage())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Usage generates a usage string from the declared subcommands, arguments and flags.
+func (m metadata) Usage(prefix string) string {
+	usage := prefix + m.Name
+	if len(m.Aliases) > 0 {
+		usage = prefix + m.Aliases[0]
+	}
+	if len(m.Subcommands) > 0 {
+		names := make([]string, len(m.Subcommands))
+		for i, s := range m.Subcommands {
+			names[i] = s.Name
+		}
+		usage += " <" + strings.Join(names, "|") + "> [args]"
+	}
+	if rest := usageOf(m.Args, m.Flags); rest != "" {
+		usage += " " + rest
+	}
+	return usage
+}
+
+// SubcommandUsage generates a usage string for a single subcommand.
+func (m metadata) SubcommandUsage(prefix string, s subcommand) string {
+	usage := prefix + m.Name
+	if len(m.Aliases) > 0 {
+		usage = prefix + m.Aliases[0]
+	}
+	usage += " " + s.Name
+	if rest := usageOf(s.Args, append(slices.Clone(m.Flags), s.Flags...)); rest != "" {
+		usage += " " + rest
+	}
+	return usage
+}
+
+func (m metadata) getSubcommand(name string) (subcommand, bool) {
+	for _, s := range m.Subcommands {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// Parses a single value according to its type.
+// Returns a human readable error if the value is invalid.
+func parseValue(name string, typ int, choices []string, raw string) (any, error) {
+	switch typ {
+	case AString, AText, AQuoted:
+		return raw, nil
+	case AUser:
+		login := strings.ToLower(strings.TrimPrefix(raw, "@"))
+		if !loginRegex.MatchString(login) {
+			return nil, fmt.Errorf("%s is not a valid username", raw)
+		}
+		return login, nil
+	case AInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", name)
+		}
+		return i, nil
+	case ADuration:
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s must be a duration like 30s, 5m or 1h", name)
+		}
+		return d, nil
+	case AEnum:
+		value := strings.ToLower(raw)
+		if !slices.Contains(choices, value) {
+			return nil, fmt.Errorf("%s must be one of: %s", name, strings.Join(choices, ", "))
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("%s has an unknown type", name)
+}
+
+// Removes the declared flags from params, and parses their values into values.
+func parseFlags(flags []flag, params []string, values map[string]any) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		if param == "--" {
+			return append(rest, params[i+1:]...), nil
+		}
+		name, isFlag := strings.CutPrefix(param, "--")
+		if !isFlag {
+			rest = append(rest, param)
+			continue
+		}
+		idx := slices.IndexFunc(flags, func(f flag) bool { return f.Name == strings.ToLower(name) })
+		if idx == -1 {
+			rest = append(rest, param)
+			continue
+		}
+		f := flags[idx]
+		if f.Type == ABool {
+			values[f.Name] = true
+			continue
+		}
+		if i+1 >= len(params) {
+			return nil, fmt.Errorf("Missing value for --%s", f.Name)
+		}
+		i++
+		value, err := parseValue(f.Name, f.Type, f.Choices, params[i])
+		if err != nil {
+			return nil, err
+		}
+		values[f.Name] = value
+	}
+	return rest, nil
+}
+
+// Joins words wrapped in double quotes into a single string, like "what game is this".
+// Returns the string and the number of words consumed.
+func quoted(params []string) (string, int) {
+	if !strings.HasPrefix(params[0], `"`) {
+		return params[0], 1
+	}
+	for i, param := range params {
+		if (i > 0 || len(param) > 1) && strings.HasSuffix(param, `"`) {
+			joined := strings.Join(params[:i+1], " ")
+			return joined[1 : len(joined)-1], i + 1
+		}
+	}
+	// No closing quote, treat it as a normal word
+	return params[0], 1
+}
+
+func parseArgs(args []argument, params []string, parent string, values map[string]any) error {
+	i := 0
+	for j, a := range args {
+		if a.FromReply && parent != "" && !isExplicit(args[j:], params[i:]) {
+			values[a.Name] = parent
+			continue
+		}
+		if i >= len(params) {
+			if a.Optional {
+				continue
+			}
+			return fmt.Errorf("Missing %s", a.Name)
+		}
+		raw := params[i]
+		consumed := 1
+		switch a.Type {
+		case AText:
+			raw = strings.Join(params[i:], " ")
+			consumed = len(params) - i
+		case AQuoted:
+			raw, consumed = quoted(params[i:])
+		}
+		i += consumed
+		value, err := parseValue(a.Name, a.Type, a.Choices, raw)
+		if err != nil {
+			return err
+		}
+		values[a.Name] = value
+	}
+	if i < len(params) {
+		return fmt.Errorf("Unexpected argument \"%s\"", params[i])
+	}
+	return nil
+}
+
+// Returns true if the first param is a value for args[0], rather than for the argument after it.
+// A word that is valid for both, like "10m" for a user followed by a duration, belongs to the later argument.
+func isExplicit(args []argument, params []string) bool {
+	if len(params) == 0 {
+		return false
+	}
+	if _, err := parseValue(args[0].Name, args[0].Type, args[0].Choices, params[0]); err != nil {
+		return false
+	}
+	if len(args) > 1 && args[1].Type != AString && args[1].Type != AText && args[1].Type != AQuoted {
+		if _, err := parseValue(args[1].Name, args[1].Type, args[1].Choices, params[0]); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse validates and parses params according to the declared subcommands,
+// arguments and flags. If the input is invalid, reply is a human readable error
+// including the usage of the command.
+func (m metadata) Parse(prefix string, params []string) (args arguments, reply string) {
+	return m.ParseReply(prefix, params, "")
+}
+
+// ParseReply is like Parse, for a message replying to a message by parent.
+// Arguments declared with FromReply default to parent if they are not passed.
+func (m metadata) ParseReply(prefix string, params []string, parent string) (args arguments, reply string) {
+	args.values = make(map[string]any)
+	flags := m.Flags
+	declared := m.Args
+	usage := m.Usage(prefix)
+
+	if len(m.Subcommands) > 0 {
+		if len(params) == 0 {
+			return args, fmt.Sprintf("Missing subcommand. Usage: %s.", usage)
+		}
+		s, found := m.getSubcommand(strings.ToLower(params[0]))
+		if !found {
+			return args, fmt.Sprintf("Unknown subcommand \"%s\". Usage: %s.", params[0], usage)
+		}
+		args.Subcommand = s.Name
+		flags = append(slices.Clone(flags), s.Flags...)
+		declared = s.Args
+		usage = m.SubcommandUsage(prefix, s)
+		params = params[1:]
+	}
+
+	params, err := parseFlags(flags, params, args.values)
+	if err != nil {
+		return args, fmt.Sprintf("%s. Usage: %s.", err, usage)
+	}
+	if err := parseArgs(declared, params, parent, args.values); err != nil {
+		return args, fmt.Sprintf("%s. Usage: %s.", err, usage)
+	}
+	return args, ""
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMetadata = metadata{
+	Name:    "test",
+	Aliases: []string{"test"},
+	Subcommands: []subcommand{
+		{
+			Name: "add",
+			Args: []argument{
+				{Name: "user", Type: AUser},
+				{Name: "count", Type: AInt, Optional: true},
+			},
+			Flags: []flag{
+				{Name: "every", Type: ADuration},
+				{Name: "live", Type: ABool},
+			},
+		},
+		{
+			Name: "say",
+			Args: []argument{
+				{Name: "mode", Type: AEnum, Choices: []string{"loud", "quiet"}},
+				{Name: "text", Type: AText},
+			},
+		},
+		{
+			Name: "reply",
+			Args: []argument{
+				{Name: "pattern", Type: AQuoted},
+				{Name: "reply", Type: AText},
+			},
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	args, reply := testMetadata.Parse("#", strings.Fields("add @LinneB 5 --every 5m --live"))
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.Subcommand != "add" {
+		t.Errorf("Expected subcommand add; Got %s", args.Subcommand)
+	}
+	if args.String("user") != "linneb" {
+		t.Errorf("Expected user linneb; Got %s", args.String("user"))
+	}
+	if args.Int("count") != 5 {
+		t.Errorf("Expected count 5; Got %d", args.Int("count"))
+	}
+	if args.Duration("every") != 5*time.Minute {
+		t.Errorf("Expected every 5m; Got %s", args.Duration("every"))
+	}
+	if !args.Bool("live") {
+		t.Errorf("Expected live flag to be set")
+	}
+
+	args, reply = testMetadata.Parse("#", strings.Fields("say LOUD hello there --live"))
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("mode") != "loud" {
+		t.Errorf("Expected mode loud; Got %s", args.String("mode"))
+	}
+	// --live is not declared for "say", so it is part of the text
+	if args.String("text") != "hello there --live" {
+		t.Errorf("Expected text \"hello there --live\"; Got %s", args.String("text"))
+	}
+
+	args, reply = testMetadata.Parse("#", strings.Fields(`reply "what game is this" It's "Minecraft"`))
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("pattern") != "what game is this" {
+		t.Errorf("Expected pattern \"what game is this\"; Got %s", args.String("pattern"))
+	}
+	if args.String("reply") != `It's "Minecraft"` {
+		t.Errorf("Expected reply \"It's \"Minecraft\"\"; Got %s", args.String("reply"))
+	}
+
+	args, _ = testMetadata.Parse("#", strings.Fields(`reply "unclosed quote`))
+	if args.String("pattern") != `"unclosed` {
+		t.Errorf("Expected pattern \"\"unclosed\"; Got %s", args.String("pattern"))
+	}
+
+	// Only AQuoted arguments join quoted words
+	m := metadata{Args: []argument{{Name: "name", Type: AString}, {Name: "reply", Type: AText}}}
+	args, _ = m.Parse("#", strings.Fields(`"foo bar" baz`))
+	if args.String("name") != `"foo` {
+		t.Errorf("Expected name \"\"foo\"; Got %s", args.String("name"))
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	m := metadata{
+		Name:    "timeout",
+		Aliases: []string{"timeout"},
+		Args: []argument{
+			{Name: "user", Type: AUser, Optional: true, FromReply: true},
+			{Name: "duration", Type: ADuration, Optional: true},
+			{Name: "reason", Type: AText, Optional: true},
+		},
+	}
+
+	args, reply := m.ParseReply("#", strings.Fields("10m spamming"), "forsen")
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("user") != "forsen" {
+		t.Errorf("Expected user forsen; Got %s", args.String("user"))
+	}
+	if args.Duration("duration") != 10*time.Minute {
+		t.Errorf("Expected duration 10m; Got %s", args.Duration("duration"))
+	}
+	if args.String("reason") != "spamming" {
+		t.Errorf("Expected reason spamming; Got %s", args.String("reason"))
+	}
+
+	// An explicit user takes precedence over the parent
+	args, reply = m.ParseReply("#", strings.Fields("bob 1h"), "forsen")
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("user") != "bob" || args.Duration("duration") != time.Hour {
+		t.Errorf("Expected user bob and duration 1h; Got %s and %s", args.String("user"), args.Duration("duration"))
+	}
+
+	ban := metadata{
+		Name:    "ban",
+		Aliases: []string{"ban"},
+		Args: []argument{
+			{Name: "user", Type: AUser, Optional: true, FromReply: true},
+			{Name: "reason", Type: AText, Optional: true},
+		},
+	}
+	args, reply = ban.ParseReply("#", strings.Fields("@Spammer"), "alice")
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("user") != "spammer" || args.Has("reason") {
+		t.Errorf("Expected user spammer and no reason; Got %s and %s", args.String("user"), args.String("reason"))
+	}
+	args, _ = ban.ParseReply("#", nil, "alice")
+	if args.String("user") != "alice" {
+		t.Errorf("Expected user alice; Got %s", args.String("user"))
+	}
+
+	// Without a parent the user is read from the message as usual
+	args, reply = m.ParseReply("#", strings.Fields("forsen 10m"), "")
+	if reply != "" {
+		t.Fatalf("Expected no error; Got %s", reply)
+	}
+	if args.String("user") != "forsen" || args.Duration("duration") != 10*time.Minute {
+		t.Errorf("Expected user forsen and duration 10m; Got %s and %s", args.String("user"), args.Duration("duration"))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "Missing subcommand. Usage: #test <add|say|reply> [args]."},
+		{"remove", "Unknown subcommand \"remove\". Usage: #test <add|say|reply> [args]."},
+		{"add", "Missing user. Usage: #test add <user> [count] [--every <every>] [--live]."},
+		{"add user! 5", "user! is not a valid username. Usage: #test add <user> [count] [--every <every>] [--live]."},
+		{"add linneb five", "count must be a whole number. Usage: #test add <user> [count] [--every <every>] [--live]."},
+		{"add linneb --every", "Missing value for --every. Usage: #test add <user> [count] [--every <every>] [--live]."},
+		{"say whisper hi", "mode must be one of: loud, quiet. Usage: #test say <loud|quiet> <text...>."},
+		{"add linneb 5 extra junk", "Unexpected argument \"extra\". Usage: #test add <user> [count] [--every <every>] [--live]."},
+		{"add linneb 5 --unknown", "Unexpected argument \"--unknown\". Usage: #test add <user> [count] [--every <every>] [--live]."},
+	}
+	for _, test := range tests {
+		_, actual := testMetadata.Parse("#", strings.Fields(test.input))
+		if actual != test.expected {
+			t.Errorf("Input %q: Expected %s; Got %s", test.input, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bot/internal/helix"
+	"bot/internal/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ban = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		login, userID, reply, err := moderationTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		err = helix.BanUser(state.Http, ctx.ChannelID, state.BotUserID, userID, ctx.Args.String("reason"))
+		if reply, ok := moderationReply(err, login); ok {
+			return reply, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Could not ban user: %w", err)
+		}
+		return fmt.Sprintf("Banned %s.", login), nil
+	},
+	Metadata: metadata{
+		Name:        "ban",
+		Description: "Permanently bans a user from the chat. Reply to a message to ban its author. The bot has to be a moderator.",
+		Cooldown:    1 * time.Second,
+		MinimumRole: RMod,
+		Aliases:     []string{"ban"},
+		Args: []argument{
+			{Name: "user", Type: AUser, Optional: true, FromReply: true},
+			{Name: "reason", Type: AText, Optional: true},
+		},
+		Examples: []example{
+			{
+				Description: "Ban a user:",
+				Command:     "#ban forsen",
+				Response:    "@linneb, Banned forsen.",
+			},
+			{
+				Description: "Ban a user with a reason:",
+				Command:     "#ban forsen spamming",
+				Response:    "@linneb, Banned forsen.",
+			},
+		},
+	},
+}
+
+// Resolves the user argument, or the author of the message being replied to, of a moderation command.
+// A non-empty reply is a user facing message explaining why the target is not valid.
+func moderationTarget(state *models.State, ctx Context) (login string, userID int, reply string, err error) {
+	login, found := ctx.UserOrParent("user")
+	if !found {
+		return "", 0, "Specify a user, or reply to one of their messages.", nil
+	}
+	userID, found, err = helix.LoginToID(state.Http, login)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("Could not get user id: %w", err)
+	}
+	if !found {
+		return "", 0, fmt.Sprintf("User %s not found.", login), nil
+	}
+	if userID == state.BotUserID {
+		return "", 0, "I can't do that to myself.", nil
+	}
+	return login, userID, "", nil
+}
+
+// Maps known moderation errors to a reply. Returns false for nil and unknown errors.
+func moderationReply(err error, login string) (string, bool) {
+	switch {
+	case err == nil:
+		return "", false
+	case errors.Is(err, helix.ErrNotModerator):
+		return "I need to be a moderator in this chat to do that.", true
+	case errors.Is(err, helix.ErrMissingScope), errors.Is(err, helix.ErrUnauthorized):
+		return "My token is missing the permissions to do that, tell the bot admin.", true
+	case errors.Is(err, helix.ErrTargetIsModerator):
+		return fmt.Sprintf("%s is a moderator or the broadcaster.", login), true
+	case errors.Is(err, helix.ErrAlreadyBanned):
+		return fmt.Sprintf("%s is already banned.", login), true
+	case errors.Is(err, helix.ErrNotBanned):
+		return fmt.Sprintf("%s is not banned or timed out.", login), true
+	case errors.Is(err, helix.ErrConflict):
+		return fmt.Sprintf("Someone else is modifying %s, try again.", login), true
+	case errors.Is(err, helix.ErrRateLimited):
+		return "Rate limited by Twitch, try again in a bit.", true
+	}
+	return "", false
+}
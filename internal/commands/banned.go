@@ -10,12 +10,13 @@ import (
 
 var banned = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) < 1 {
-			return fmt.Sprintf("Missing username. Usage: %s <user>", ctx.Command), nil
+		login, found := ctx.UserOrParent("user")
+		if !found {
+			return "Specify a user, or reply to one of their messages.", nil
 		}
 		req := http.Request{
 			Method: "GET",
-			URL:    "https://api.ivr.fi/v2/twitch/user?login=" + ctx.Parameters[0],
+			URL:    "https://api.ivr.fi/v2/twitch/user?login=" + login,
 		}
 		res, err := state.Http.GenericRequest(req)
 		if err != nil {
@@ -42,7 +43,7 @@ var banned = command{
 			return "", fmt.Errorf("Could not decode json: %w", models.NewSystemError(err))
 		}
 		if len(body) == 0 {
-			return fmt.Sprintf("User %s not found.", ctx.Parameters[0]), nil
+			return fmt.Sprintf("User %s not found.", login), nil
 		}
 		if body[0].Banned {
 			return fmt.Sprintf("%s is BANNED: %s BOP", body[0].DisplayName, body[0].BanReason), nil
@@ -51,11 +52,12 @@ var banned = command{
 	},
 	Metadata: metadata{
 		Name:        "banned",
-		Description: "Checks if a user is banned on Twitch.",
+		Description: "Checks if a user is banned on Twitch. Reply to a message to check its author.",
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"banned"},
-		Usage:       "#banned <user>",
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Check if a user is banned:",
@@ -3,38 +3,84 @@ package commands
 import (
 	"bot/internal/database"
 	"bot/internal/models"
+	"bot/internal/utils"
+	"bot/internal/variables"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 )
 
 var cmd = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) == 0 {
-			return fmt.Sprintf("Missing subcommand: Usage: %s <add|remove|edit> [args].", ctx.Command), nil
-		}
+		commandName := strings.ToLower(ctx.Args.String("name"))
+		switch ctx.Args.Subcommand {
+		case "list":
+			cmds, err := database.GetCommands(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get commands: %w", err)
+			}
+			if len(cmds) == 0 {
+				return "There are no commands in this chat.", nil
+			}
+			return fmt.Sprintf("%d command%s: %s/chat/%s/commands", len(cmds), utils.PluraliseInt(len(cmds)), state.Config.PublicURL, ctx.ChannelName), nil
 
-		subcommand := ctx.Parameters[0]
-		if subcommand != "add" && subcommand != "remove" && subcommand != "edit" {
-			return fmt.Sprintf("Invalid subcommand: Usage: %s <add|remove|edit> [args].", ctx.Command), nil
-		}
+		case "remove":
+			names := strings.Fields(strings.ToLower(ctx.Args.String("names")))
+			deleted, err := database.DeleteCommands(state.DB, ctx.ChannelID, names)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete commands: %w", err)
+			}
+			var deletedNames []string
+			for _, command := range deleted {
+				err = recordHistory(state, ctx, models.HistoryDelete, &command, nil)
+				if err != nil {
+					return "", err
+				}
+				deletedNames = append(deletedNames, command.Name)
+			}
+			slices.Sort(deletedNames)
+			if len(deletedNames) == 0 {
+				return "None of those are commands.", nil
+			}
+			if len(deletedNames) == 1 {
+				return fmt.Sprintf("Removed command \"%s\".", deletedNames[0]), nil
+			}
+			return fmt.Sprintf("Removed %d commands: %s.", len(deletedNames), strings.Join(deletedNames, ", ")), nil
 
-		switch subcommand {
-		case "add":
-			if len(ctx.Parameters) < 2 {
-				return fmt.Sprintf("Missing command name: Usage: %s add <name> <reply>.", ctx.Command), nil
+		case "history":
+			history, err := database.GetCommandHistory(state.DB, ctx.ChannelID, commandName, 5)
+			if err != nil {
+				return "", fmt.Errorf("Could not get command history: %w", err)
 			}
-			if len(ctx.Parameters) < 3 {
-				return fmt.Sprintf("Missing command reply: Usage: %s add <name> <reply>.", ctx.Command), nil
+			if len(history) == 0 {
+				return fmt.Sprintf("%s has no history.", commandName), nil
 			}
-			commandName := strings.ToLower(ctx.Parameters[1])
-			reply := strings.Join(ctx.Parameters[2:], " ")
-			if len(commandName) >= 100 {
-				return "Command name is too long! (max 100 characters).", nil
+			changes := make([]string, len(history))
+			for i, entry := range history {
+				changes[i] = describeChange(entry)
+			}
+			return fmt.Sprintf("%s. Full history: %s/chat/%s/history", strings.Join(changes, ", "), state.Config.PublicURL, ctx.ChannelName), nil
+
+		case "undo":
+			return undoChange(state, ctx, commandName)
+
+		case "rename":
+			return renameCommands(state, ctx, ctx.Args.String("renames"))
+		}
+
+		if ctx.Args.Subcommand == "add" {
+			reply := ctx.Args.String("reply")
+			if invalid := validateCommandName(commandName, "Command name"); invalid != "" {
+				return invalid, nil
 			}
 			if len(reply) >= 400 {
 				return "Command reply is too long! (max 400 characters).", nil
 			}
+			if _, err := variables.Parse(reply); err != nil {
+				return fmt.Sprintf("Invalid reply: %s.", err), nil
+			}
 			if _, found := Handler.GetCommandByAlias(commandName); found {
 				return fmt.Sprintf("%s is already a command.", commandName), nil
 			}
@@ -47,88 +93,531 @@ var cmd = command{
 				return fmt.Sprintf("%s is already a command.", commandName), nil
 			}
 
-			err = database.CreateCommand(state.DB, models.Command{
-				ChatID: ctx.ChannelID,
-				Name:   commandName,
-				Reply:  reply,
-			})
+			command := models.Command{
+				ChatID:      ctx.ChannelID,
+				Name:        commandName,
+				Reply:       reply,
+				Cooldown:    1000,
+				MinimumRole: RGeneric,
+				Enabled:     true,
+				CreatedBy:   ctx.SenderUsername,
+			}
+			err = database.CreateCommand(state.DB, command)
 			if err != nil {
 				return "", fmt.Errorf("Could not create command: %w", err)
 			}
+			err = recordHistory(state, ctx, models.HistoryCreate, nil, &command)
+			if err != nil {
+				return "", err
+			}
 			return fmt.Sprintf("Added command \"%s\".", commandName), nil
+		}
 
-		case "remove":
-			if len(ctx.Parameters) < 2 {
-				return fmt.Sprintf("Missing command name: Usage: %s remove <name>.", ctx.Command), nil
-			}
-			commandName := strings.ToLower(ctx.Parameters[1])
+		command, found, err := database.GetCommand(state.DB, ctx.ChannelID, commandName)
+		if err != nil {
+			return "", fmt.Errorf("Could not get command: %w", err)
+		}
+		if !found {
+			return fmt.Sprintf("%s is not a command.", commandName), nil
+		}
+		original := command
+		original.Aliases = slices.Clone(command.Aliases)
 
-			command, found, err := database.GetCommand(state.DB, ctx.ChannelID, commandName)
-			if err != nil {
-				return "", fmt.Errorf("Could not get command: %w", err)
+		switch ctx.Args.Subcommand {
+		case "edit":
+			reply := ctx.Args.String("reply")
+			if len(reply) >= 400 {
+				return "Command reply is too long! (max 400 characters).", nil
 			}
-			if !found {
-				return fmt.Sprintf("%s is not a command.", commandName), nil
+			if _, err := variables.Parse(reply); err != nil {
+				return fmt.Sprintf("Invalid reply: %s.", err), nil
 			}
-
-			err = database.DeleteCommand(state.DB, command)
+			err = database.UpdateCommand(state.DB, command, reply)
 			if err != nil {
-				return "", fmt.Errorf("Could not delete command: %w", err)
+				return "", fmt.Errorf("Could not update command: %w", err)
+			}
+			command.Reply = reply
+			err = recordHistory(state, ctx, models.HistoryEdit, &original, &command)
+			if err != nil {
+				return "", err
 			}
-			return fmt.Sprintf("Removed command \"%s\".", commandName), nil
+			return fmt.Sprintf("Edited command \"%s\".", command.Name), nil
 
-		case "edit":
-			if len(ctx.Parameters) < 2 {
-				return fmt.Sprintf("Missing command name: Usage: %s edit <name> <reply>.", ctx.Command), nil
+		case "info":
+			status := "enabled"
+			if !command.Enabled {
+				status = "disabled"
 			}
-			if len(ctx.Parameters) < 3 {
-				return fmt.Sprintf("Missing command reply: Usage: %s edit <name> <reply>.", ctx.Command), nil
+			aliases := "none"
+			if len(command.Aliases) > 0 {
+				aliases = strings.Join(command.Aliases, ", ")
 			}
-			commandName := strings.ToLower(ctx.Parameters[1])
-			reply := strings.Join(ctx.Parameters[2:], " ")
-			if len(reply) >= 400 {
-				return "Command reply is too long! (max 400 characters).", nil
+			creator := ""
+			if command.CreatedBy != "" {
+				creator = " by " + command.CreatedBy
 			}
+			return fmt.Sprintf(
+				"%s is %s. Cooldown: %s. Minimum role: %s. Aliases: [%s]. Used %d time%s. Created%s %s ago, last updated %s ago.",
+				command.Name,
+				status,
+				time.Duration(command.Cooldown)*time.Millisecond,
+				PrettyRole(command.MinimumRole),
+				aliases,
+				command.Uses,
+				utils.PluraliseInt(command.Uses),
+				creator,
+				utils.PrettyDuration(time.Since(command.CreatedAt)),
+				utils.PrettyDuration(time.Since(command.UpdatedAt)),
+			), nil
+
+		case "cooldown":
+			cooldown := ctx.Args.Duration("cooldown")
+			if cooldown < time.Second {
+				return "Cooldown must be at least 1 second.", nil
+			}
+			command.Cooldown = int(cooldown.Milliseconds())
+
+		case "role":
+			command.MinimumRole = roleNames[ctx.Args.String("role")]
 
-			command, found, err := database.GetCommand(state.DB, ctx.ChannelID, commandName)
+		case "enable", "disable":
+			command.Enabled = ctx.Args.Subcommand == "enable"
+
+		case "alias":
+			alias := strings.ToLower(ctx.Args.String("alias"))
+			if invalid := validateCommandName(alias, "Alias"); invalid != "" {
+				return invalid, nil
+			}
+			if _, found := Handler.GetCommandByAlias(alias); found {
+				return fmt.Sprintf("%s is already a command.", alias), nil
+			}
+			_, found, err := database.GetCommand(state.DB, ctx.ChannelID, alias)
 			if err != nil {
 				return "", fmt.Errorf("Could not get command: %w", err)
 			}
-			if !found {
-				return fmt.Sprintf("%s is not a command.", commandName), nil
+			if found {
+				return fmt.Sprintf("%s is already a command.", alias), nil
 			}
+			command.Aliases = append(command.Aliases, alias)
 
-			err = database.UpdateCommand(state.DB, command, reply)
-			if err != nil {
-				return "", fmt.Errorf("Could not update command: %w", err)
+		case "unalias":
+			alias := strings.ToLower(ctx.Args.String("alias"))
+			if !slices.Contains(command.Aliases, alias) {
+				return fmt.Sprintf("%s is not an alias of %s.", alias, command.Name), nil
 			}
-			return fmt.Sprintf("Edited command \"%s\".", commandName), nil
+			command.Aliases = slices.DeleteFunc(command.Aliases, func(a string) bool { return a == alias })
+		}
+
+		err = database.UpdateCommandSettings(state.DB, command)
+		if err != nil {
+			return "", fmt.Errorf("Could not update command: %w", err)
+		}
+		err = recordHistory(state, ctx, models.HistoryUpdate, &original, &command)
+		if err != nil {
+			return "", err
 		}
-		return "", fmt.Errorf("This error is impossible and will never happen")
+		return fmt.Sprintf("Updated command \"%s\".", command.Name), nil
 	},
 	Metadata: metadata{
-		Name:        "cmd",
-		Description: "Add/remove/edit static commands.",
-		Cooldown:    1 * time.Second,
-		MinimumRole: RMod,
-		Aliases:     []string{"cmd", "command"},
-		Usage:       "#cmd <add|remove|edit> [args]",
+		Name:                "cmd",
+		Description:         "Add/remove/edit and configure static commands.",
+		ExtendedDescription: "Replies can contain variables: ${sender} (the user running the command), ${user} (first argument, the author of the replied-to message, or the sender), ${channel}, ${1} to ${9} (positional arguments), ${args} (all arguments), ${random 1-100}, ${choice a|b|c}, ${uptime} (uptime of the current stream), ${followers} (follower count of the current chat), ${count name} (value of a counter) and ${increment name} (increment a counter and show the new value).",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"cmd", "command"},
+		Subcommands: []subcommand{
+			{
+				Name:        "add",
+				Description: "Add a new command.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "reply", Type: AText},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove one or more commands.",
+				Args: []argument{
+					{Name: "names", Type: AText},
+				},
+			},
+			{
+				Name:        "rename",
+				Description: "Change the name of a command, or of multiple commands with old=new pairs. Aliases, settings and usage count are kept.",
+				Args:        []argument{{Name: "renames", Type: AText}},
+			},
+			{
+				Name:        "history",
+				Description: "Show the latest changes to a command, including removed commands.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "undo",
+				Description: "Undo the latest change to a command. Can be used multiple times to go further back.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "list",
+				Description: "Link to a page with all commands in the chat. The page also has a JSON export that can be imported with #import.",
+			},
+			{
+				Name:        "edit",
+				Description: "Change the reply of a command.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "reply", Type: AText},
+				},
+			},
+			{
+				Name:        "info",
+				Description: "Show the settings and usage count of a command.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "cooldown",
+				Description: "Change the per-user cooldown of a command. Defaults to 1 second.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "cooldown", Type: ADuration},
+				},
+			},
+			{
+				Name:        "role",
+				Description: "Change the minimum role required to use a command.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "role", Type: AEnum, Choices: roleChoices},
+				},
+			},
+			{
+				Name:        "enable",
+				Description: "Enable a disabled command.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "disable",
+				Description: "Disable a command without removing it.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "alias",
+				Description: "Add an alias to a command.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "alias", Type: AString},
+				},
+			},
+			{
+				Name:        "unalias",
+				Description: "Remove an alias from a command.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "alias", Type: AString},
+				},
+			},
+		},
 		Examples: []example{
 			{
 				Description: "Add a command to the current chat:",
 				Command:     "#cmd add test This is a test command! :D",
 				Response:    "@linneb, Added command \"test\".",
 			},
+			{
+				Description: "Replies can contain variables:",
+				Command:     "#cmd add hug ${sender} hugs ${user} <3",
+				Response:    "@linneb, Added command \"hug\".",
+			},
 			{
 				Description: "Edit the command:",
 				Command:     "#cmd edit test This is the 2nd iteration of the test command! :o",
 				Response:    "@linneb, Edited command \"test\".",
 			},
 			{
-				Description: "Remove the command:",
-				Command:     "#cmd remove test",
-				Response:    "@linneb, Removed command \"test\".",
+				Description: "Make the command mod-only, with a 10 second cooldown:",
+				Command:     "#cmd role test mod",
+				Response:    "@linneb, Updated command \"test\".",
+			},
+			{
+				Command:  "#cmd cooldown test 10s",
+				Response: "@linneb, Updated command \"test\".",
+			},
+			{
+				Description: "Show information about the command:",
+				Command:     "#cmd info test",
+				Response:    "@linneb, test is enabled. Cooldown: 10s. Minimum role: Mod. Aliases: [none]. Used 3 times. Created by linneb 2 days ago, last updated 5 minutes ago.",
+			},
+			{
+				Description: "Rename the command:",
+				Command:     "#cmd rename test test2",
+				Response:    "@linneb, Renamed command \"test\" to \"test2\".",
+			},
+			{
+				Description: "Rename multiple commands at once:",
+				Command:     "#cmd rename hi=hello bye=goodbye",
+				Response:    "@linneb, Renamed 2 commands: hi to hello, bye to goodbye.",
+			},
+			{
+				Description: "Remove commands:",
+				Command:     "#cmd remove test2 hug",
+				Response:    "@linneb, Removed 2 commands: hug, test2.",
+			},
+			{
+				Description: "Show the history of a removed command, and restore it:",
+				Command:     "#cmd history test2",
+				Response:    "@linneb, deleted by linneb 1 minute ago, renamed from test by linneb 2 minutes ago, edited by linneb 5 minutes ago, created by linneb 2 days ago. Full history: https://bot.linneb.xyz/chat/linneb/history",
+			},
+			{
+				Command:  "#cmd undo test2",
+				Response: "@linneb, Restored command \"test2\".",
+			},
+			{
+				Description: "List all commands in the chat:",
+				Command:     "#cmd list",
+				Response:    "@linneb, 12 commands: https://bot.linneb.xyz/chat/linneb/commands",
 			},
 		},
 	},
 }
+
+// Renames one command given as "old new", or multiple commands given as "old=new" pairs.
+// All commands are renamed in one transaction, so nothing is renamed if any pair is invalid.
+func renameCommands(state *models.State, ctx Context, text string) (string, error) {
+	type rename struct {
+		command models.Command
+		newName string
+	}
+	fields := strings.Fields(strings.ToLower(text))
+	var pairs [][2]string
+	if len(fields) == 2 && !strings.Contains(text, "=") {
+		pairs = append(pairs, [2]string{fields[0], fields[1]})
+	} else {
+		for _, field := range fields {
+			oldName, newName, found := strings.Cut(field, "=")
+			if !found || oldName == "" || newName == "" {
+				return fmt.Sprintf("%s is not an old=new pair.", field), nil
+			}
+			pairs = append(pairs, [2]string{oldName, newName})
+		}
+	}
+
+	var renames []rename
+	renamed := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, pair := range pairs {
+		oldName, newName := pair[0], pair[1]
+		if invalid := validateCommandName(newName, "Command name"); invalid != "" {
+			return invalid, nil
+		}
+		command, found, err := database.GetCommand(state.DB, ctx.ChannelID, oldName)
+		if err != nil {
+			return "", fmt.Errorf("Could not get command: %w", err)
+		}
+		if !found {
+			return fmt.Sprintf("%s is not a command.", oldName), nil
+		}
+		if _, found := renamed[command.Name]; found {
+			return fmt.Sprintf("%s is renamed more than once.", command.Name), nil
+		}
+		if taken[newName] {
+			return fmt.Sprintf("%s is used as a new name more than once.", newName), nil
+		}
+		if _, found := Handler.GetCommandByAlias(newName); found {
+			return fmt.Sprintf("%s is already a command.", newName), nil
+		}
+		existing, found, err := database.GetCommand(state.DB, ctx.ChannelID, newName)
+		if err != nil {
+			return "", fmt.Errorf("Could not get command: %w", err)
+		}
+		if found && existing.Name != command.Name {
+			return fmt.Sprintf("%s is already a command.", newName), nil
+		}
+		renamed[command.Name] = newName
+		taken[newName] = true
+		renames = append(renames, rename{command: command, newName: newName})
+	}
+	if len(renames) == 0 {
+		return "Specify a command and its new name, or old=new pairs.", nil
+	}
+
+	err := database.RenameCommands(state.DB, ctx.ChannelID, renamed)
+	if err != nil {
+		return "", fmt.Errorf("Could not rename commands: %w", err)
+	}
+	var descriptions []string
+	for _, r := range renames {
+		command := r.command
+		command.Aliases = slices.DeleteFunc(slices.Clone(command.Aliases), func(a string) bool { return a == r.newName })
+		command.Name = r.newName
+		err = recordHistory(state, ctx, models.HistoryRename, &r.command, &command)
+		if err != nil {
+			return "", err
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s to %s", r.command.Name, r.newName))
+	}
+	if len(renames) == 1 {
+		return fmt.Sprintf("Renamed command \"%s\" to \"%s\".", renames[0].command.Name, renames[0].newName), nil
+	}
+	return fmt.Sprintf("Renamed %d commands: %s.", len(renames), strings.Join(descriptions, ", ")), nil
+}
+
+// Checks a command name or alias. Returns a user facing message if it is invalid, otherwise "".
+func validateCommandName(name string, what string) string {
+	if name == "" {
+		return fmt.Sprintf("%s can not be empty.", what)
+	}
+	if strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return fmt.Sprintf("%s can not contain spaces.", what)
+	}
+	if len(name) >= 100 {
+		return fmt.Sprintf("%s is too long! (max 100 characters).", what)
+	}
+	return ""
+}
+
+func recordHistory(state *models.State, ctx Context, action string, old *models.Command, new *models.Command) error {
+	entry := models.CommandHistory{
+		ChatID: ctx.ChannelID,
+		Action: action,
+		Actor:  ctx.SenderUsername,
+		Old:    old,
+		New:    new,
+	}
+	if new != nil {
+		entry.Name = new.Name
+	} else {
+		entry.Name = old.Name
+	}
+	err := database.AddCommandHistory(state.DB, entry)
+	if err != nil {
+		return fmt.Errorf("Could not record command history: %w", err)
+	}
+	return nil
+}
+
+func describeChange(entry models.CommandHistory) string {
+	action := entry.Action + "d"
+	switch entry.Action {
+	case models.HistoryEdit:
+		action = "edited"
+	case models.HistoryRename:
+		action = fmt.Sprintf("renamed from %s", entry.Old.Name)
+	}
+	description := fmt.Sprintf("%s by %s %s ago", action, entry.Actor, utils.PrettyDuration(time.Since(entry.CreatedAt)))
+	if entry.Undone {
+		description += " (undone)"
+	}
+	return description
+}
+
+// Reverts the latest change to a command that has not been undone.
+func undoChange(state *models.State, ctx Context, name string) (string, error) {
+	entry, found, err := database.GetLastCommandChange(state.DB, ctx.ChannelID, name)
+	if err != nil {
+		return "", fmt.Errorf("Could not get command history: %w", err)
+	}
+	if !found {
+		return fmt.Sprintf("%s has no changes to undo.", name), nil
+	}
+	current, exists, err := database.GetCommand(state.DB, ctx.ChannelID, entry.Name)
+	if err != nil {
+		return "", fmt.Errorf("Could not get command: %w", err)
+	}
+	// Only the name has to match, aliases of other commands are fine
+	exists = exists && current.Name == entry.Name
+
+	var reply string
+	switch entry.Action {
+	case models.HistoryCreate:
+		if !exists {
+			return fmt.Sprintf("%s no longer exists.", entry.Name), nil
+		}
+		_, err = database.DeleteCommands(state.DB, ctx.ChannelID, []string{entry.Name})
+		if err != nil {
+			return "", fmt.Errorf("Could not delete command: %w", err)
+		}
+		reply = fmt.Sprintf("Removed command \"%s\".", entry.Name)
+
+	case models.HistoryEdit, models.HistoryUpdate:
+		if !exists {
+			return fmt.Sprintf("%s no longer exists.", entry.Name), nil
+		}
+		old := *entry.Old
+		old.Aliases, err = freeAliases(state, ctx.ChannelID, old.Name, old.Aliases)
+		if err != nil {
+			return "", err
+		}
+		err = database.RestoreCommand(state.DB, old)
+		if err != nil {
+			return "", fmt.Errorf("Could not restore command: %w", err)
+		}
+		reply = fmt.Sprintf("Restored the previous version of \"%s\".", entry.Name)
+
+	case models.HistoryDelete:
+		if exists {
+			return fmt.Sprintf("%s already exists.", entry.Name), nil
+		}
+		old := *entry.Old
+		old.Aliases, err = freeAliases(state, ctx.ChannelID, old.Name, old.Aliases)
+		if err != nil {
+			return "", err
+		}
+		err = database.RestoreCommand(state.DB, old)
+		if err != nil {
+			return "", fmt.Errorf("Could not restore command: %w", err)
+		}
+		reply = fmt.Sprintf("Restored command \"%s\".", entry.Name)
+
+	case models.HistoryRename:
+		if !exists {
+			return fmt.Sprintf("%s no longer exists.", entry.Name), nil
+		}
+		_, taken, err := database.GetCommand(state.DB, ctx.ChannelID, entry.Old.Name)
+		if err != nil {
+			return "", fmt.Errorf("Could not get command: %w", err)
+		}
+		if taken {
+			return fmt.Sprintf("%s is already a command.", entry.Old.Name), nil
+		}
+		err = database.RenameCommand(state.DB, current, entry.Old.Name)
+		if err != nil {
+			return "", fmt.Errorf("Could not rename command: %w", err)
+		}
+		current.Name = entry.Old.Name
+		current.Aliases, err = freeAliases(state, ctx.ChannelID, current.Name, entry.Old.Aliases)
+		if err != nil {
+			return "", err
+		}
+		err = database.UpdateCommandSettings(state.DB, current)
+		if err != nil {
+			return "", fmt.Errorf("Could not update command: %w", err)
+		}
+		reply = fmt.Sprintf("Renamed command \"%s\" back to \"%s\".", entry.Name, entry.Old.Name)
+
+	default:
+		return "", fmt.Errorf("Unknown history action %s", entry.Action)
+	}
+
+	err = database.SetCommandHistoryUndone(state.DB, entry.ID, ctx.SenderUsername)
+	if err != nil {
+		return "", fmt.Errorf("Could not update command history: %w", err)
+	}
+	return reply, nil
+}
+
+// Removes aliases that are used by commands other than owner.
+func freeAliases(state *models.State, chatID int, owner string, aliases []string) ([]string, error) {
+	var free []string
+	for _, alias := range aliases {
+		if _, found := Handler.GetCommandByAlias(alias); found {
+			continue
+		}
+		command, found, err := database.GetCommand(state.DB, chatID, alias)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get command: %w", err)
+		}
+		if !found || command.Name == owner {
+			free = append(free, alias)
+		}
+	}
+	return free, nil
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/variables"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var counter = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		name := strings.ToLower(ctx.Args.String("name"))
+		if !variables.CounterNameRegex.MatchString(name) {
+			return "Counter names can only contain a-z, 0-9 and _ (max 50 characters).", nil
+		}
+
+		switch ctx.Args.Subcommand {
+		case "get":
+			value, err := database.GetCounter(state.DB, ctx.ChannelID, name)
+			if err != nil {
+				return "", fmt.Errorf("Could not get counter: %w", err)
+			}
+			return fmt.Sprintf("%s is %d.", name, value), nil
+		case "set":
+			value := ctx.Args.Int("value")
+			err = database.SetCounter(state.DB, ctx.ChannelID, name, value)
+			if err != nil {
+				return "", fmt.Errorf("Could not set counter: %w", err)
+			}
+			return fmt.Sprintf("Set %s to %d.", name, value), nil
+		case "reset":
+			err = database.DeleteCounter(state.DB, ctx.ChannelID, name)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete counter: %w", err)
+			}
+			return fmt.Sprintf("Reset %s to 0.", name), nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:                "counter",
+		Description:         "Get/set/reset counters used in static commands.",
+		ExtendedDescription: "Counters are numbers stored per chat, which can be shown and incremented from static command replies using ${count name} and ${increment name}. Counters are created the first time they are incremented or set.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"counter", "count"},
+		Subcommands: []subcommand{
+			{
+				Name:        "get",
+				Description: "Show the value of a counter.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "set",
+				Description: "Change the value of a counter.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "value", Type: AInt},
+				},
+			},
+			{
+				Name:        "reset",
+				Description: "Reset a counter to 0.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Create a command that counts deaths:",
+				Command:     "#cmd add deaths Streamer has died ${increment deaths} times!",
+				Response:    "@linneb, Added command \"deaths\".",
+			},
+			{
+				Description: "Every use increments the counter:",
+				Command:     "#deaths",
+				Response:    "@linneb, Streamer has died 1 times!",
+			},
+			{
+				Description: "Fix the count:",
+				Command:     "#counter set deaths 41",
+				Response:    "@linneb, Set deaths to 41.",
+			},
+			{
+				Description: "Start over:",
+				Command:     "#counter reset deaths",
+				Response:    "@linneb, Reset deaths to 0.",
+			},
+		},
+	},
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/filters"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxFilters = 50
+
+var filterKinds = []string{
+	models.FilterPhrase,
+	models.FilterRegex,
+	models.FilterLinks,
+	models.FilterCaps,
+	models.FilterSymbols,
+	models.FilterEmotes,
+	models.FilterRepeat,
+	models.FilterLength,
+}
+
+var filterActions = []string{models.ActionDelete, models.ActionTimeout, models.ActionBan, models.ActionWarn}
+
+func describeFilter(f models.Filter) string {
+	description := fmt.Sprintf("#%d %s", f.ID, f.Kind)
+	switch f.Kind {
+	case models.FilterPhrase, models.FilterRegex:
+		description += fmt.Sprintf(" \"%s\"", f.Pattern)
+	case models.FilterCaps, models.FilterSymbols:
+		description += fmt.Sprintf(" >%d%%", f.Threshold)
+	case models.FilterEmotes, models.FilterRepeat, models.FilterLength:
+		description += fmt.Sprintf(" >%d", f.Threshold)
+	}
+	description += " (" + f.Action
+	if f.Action == models.ActionTimeout {
+		description += " " + utils.PrettyDuration(time.Duration(f.Duration)*time.Second)
+	}
+	return description + ")"
+}
+
+var filter = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		switch ctx.Args.Subcommand {
+		case "add":
+			existing, err := database.GetFilters(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get filters: %w", err)
+			}
+			if len(existing) >= maxFilters {
+				return fmt.Sprintf("This chat already has the maximum of %d filters.", maxFilters), nil
+			}
+			f := models.Filter{
+				ChatID:    ctx.ChannelID,
+				Kind:      ctx.Args.String("kind"),
+				Pattern:   ctx.Args.String("pattern"),
+				Threshold: filters.DefaultThresholds[ctx.Args.String("kind")],
+				Action:    models.ActionDelete,
+			}
+			if f.Kind == models.FilterPhrase || f.Kind == models.FilterRegex {
+				if f.Pattern == "" {
+					return fmt.Sprintf("%s filters need a pattern.", f.Kind), nil
+				}
+				if len(f.Pattern) > 200 {
+					return "Pattern is too long! (max 200 characters).", nil
+				}
+			} else {
+				f.Pattern = ""
+			}
+			if ctx.Args.Has("threshold") {
+				f.Threshold = ctx.Args.Int("threshold")
+				if f.Threshold < 0 {
+					return "Threshold can not be negative.", nil
+				}
+			}
+			if ctx.Args.Has("action") {
+				f.Action = ctx.Args.String("action")
+			}
+			if f.Action == models.ActionTimeout {
+				duration := 10 * time.Minute
+				if ctx.Args.Has("duration") {
+					duration = ctx.Args.Duration("duration")
+				}
+				if duration < time.Second || duration > 14*24*time.Hour {
+					return "Timeout duration must be between 1 second and 2 weeks.", nil
+				}
+				f.Duration = int(duration.Seconds())
+			}
+			if err := filters.Validate(f); err != nil {
+				return fmt.Sprintf("Invalid regex: %s.", err), nil
+			}
+			f.ID, err = database.CreateFilter(state.DB, f)
+			if err != nil {
+				return "", fmt.Errorf("Could not create filter: %w", err)
+			}
+			filters.Invalidate(ctx.ChannelID)
+			return fmt.Sprintf("Added filter %s.", describeFilter(f)), nil
+
+		case "remove":
+			f, found, err := database.GetFilter(state.DB, ctx.ChannelID, ctx.Args.Int("id"))
+			if err != nil {
+				return "", fmt.Errorf("Could not get filter: %w", err)
+			}
+			if !found {
+				return fmt.Sprintf("Filter #%d not found.", ctx.Args.Int("id")), nil
+			}
+			err = database.DeleteFilter(state.DB, f)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete filter: %w", err)
+			}
+			filters.Invalidate(ctx.ChannelID)
+			return fmt.Sprintf("Removed filter #%d.", f.ID), nil
+
+		case "list":
+			existing, err := database.GetFilters(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get filters: %w", err)
+			}
+			if len(existing) == 0 {
+				return "This chat does not have any filters.", nil
+			}
+			descriptions := make([]string, len(existing))
+			for i, f := range existing {
+				descriptions[i] = describeFilter(f)
+			}
+			return fmt.Sprintf("Filters: %s.", strings.Join(descriptions, ", ")), nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:                "filter",
+		Description:         "Add/remove moderation filters for chat messages.",
+		ExtendedDescription: "Filters delete messages, time out, ban or warn users when a message matches. The bot must be a moderator for filters to work. Moderators, VIPs and the broadcaster are never filtered. Kinds: phrase (case insensitive text), regex, links (see #permit), caps (% of letters, default 70), symbols (% of characters, default 50), emotes (number of Twitch emotes, default 10), repeat (identical messages in a row, default 2) and length (characters, default 300). Messages above the threshold are filtered.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"filter", "filters"},
+		Subcommands: []subcommand{
+			{
+				Name:        "add",
+				Description: "Add a new filter. Phrase and regex filters need a pattern.",
+				Args: []argument{
+					{Name: "kind", Type: AEnum, Choices: filterKinds},
+					{Name: "pattern", Type: AText, Optional: true},
+				},
+				Flags: []flag{
+					{Name: "action", Type: AEnum, Choices: filterActions, Description: "What to do when a message matches. Defaults to delete."},
+					{Name: "duration", Type: ADuration, Description: "Length of timeouts. Defaults to 10 minutes."},
+					{Name: "threshold", Type: AInt, Description: "Limit for caps, symbols, emotes, repeat and length filters."},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a filter by ID.",
+				Args:        []argument{{Name: "id", Type: AInt}},
+			},
+			{
+				Name:        "list",
+				Description: "List all filters in the current chat.",
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Time out users that say a phrase:",
+				Command:     "#filter add phrase buy followers --action timeout --duration 1h",
+				Response:    "@linneb, Added filter #1 phrase \"buy followers\" (timeout 1 hour).",
+			},
+			{
+				Description: "Delete links, unless the user has been permitted:",
+				Command:     "#filter add links",
+				Response:    "@linneb, Added filter #2 links (delete).",
+			},
+			{
+				Description: "Warn users that write mostly in caps:",
+				Command:     "#filter add caps --threshold 80 --action warn",
+				Response:    "@linneb, Added filter #3 caps >80% (warn).",
+			},
+			{
+				Description: "Remove a filter:",
+				Command:     "#filter remove 1",
+				Response:    "@linneb, Removed filter #1.",
+			},
+		},
+	},
+}
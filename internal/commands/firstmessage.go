@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+)
+
+var firstmessage = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if reply, err := loggingDisabled(state, ctx); reply != "" || err != nil {
+			return reply, err
+		}
+		login, userID, reply, err := logTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		entry, found, err := database.GetFirstMessage(state.DB, ctx.ChannelID, userID)
+		if err != nil {
+			return "", fmt.Errorf("Could not get first message: %w", err)
+		}
+		if !found {
+			return fmt.Sprintf("No logged messages from %s in this chat.", login), nil
+		}
+		return fmt.Sprintf("%s's first logged message was %s ago: %s", entry.DisplayName, utils.PrettyDuration(time.Since(entry.SentAt)), entry.Text), nil
+	},
+	Metadata: metadata{
+		Name:        "firstmessage",
+		Description: "Shows the first logged message of a user in the current chat.",
+		Cooldown:    3 * time.Second,
+		MinimumRole: RGeneric,
+		Aliases:     []string{"firstmessage", "fm"},
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Get the first message of a user:",
+				Command:     "#firstmessage forsen",
+				Response:    "@linneb, forsen's first logged message was 2 months ago: hi chat",
+			},
+		},
+	},
+}
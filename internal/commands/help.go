@@ -11,8 +11,8 @@ import (
 var help = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
 		commandName := "help"
-		if len(ctx.Parameters) > 0 {
-			commandName = ctx.Parameters[0]
+		if ctx.Args.Has("command") {
+			commandName = strings.ToLower(ctx.Args.String("command"))
 		}
 		command, found := Handler.GetCommandByName(commandName)
 		if !found {
@@ -21,21 +21,31 @@ var help = command{
 				return "Command name/alias not found.", nil
 			}
 		}
-		return fmt.Sprintf("%s: %s Aliases: [%s]. Minimum role: %s. Usage: \"%s\".",
+		reply = fmt.Sprintf("%s: %s Aliases: [%s]. Minimum role: %s. Usage: \"%s\".",
 			utils.CapitalizeFirstCharacter(command.Metadata.Name),
 			command.Metadata.Description,
 			strings.Join(command.Metadata.Aliases, ", "),
 			command.Metadata.PrettyRole(),
-			command.Metadata.Usage,
-		), nil
+			command.Metadata.Usage(ctx.Prefix),
+		)
+		if len(command.Metadata.Subcommands) > 0 {
+			usages := make([]string, len(command.Metadata.Subcommands))
+			for i, s := range command.Metadata.Subcommands {
+				usages[i] = command.Metadata.SubcommandUsage(ctx.Prefix, s)
+			}
+			reply += fmt.Sprintf(" Subcommands: %s.", strings.Join(usages, ", "))
+		}
+		return reply, nil
 	},
 	Metadata: metadata{
-		Name:        "help",
-		Description: "Shows a help message for a specific command.",
-		Cooldown:    1 * time.Second,
-		MinimumRole: RGeneric,
-		Aliases:     []string{"help", "usage"},
-		Usage:       "#help [command]",
+		Name:                "help",
+		Description:         "Shows a help message for a specific command.",
+		ExtendedDescription: "Help for commands with many subcommands is whispered, since it does not fit in one chat message.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RGeneric,
+		Whisper:             WAllowed,
+		Aliases:             []string{"help", "usage"},
+		Args:                []argument{{Name: "command", Type: AString, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Get some information about a command:",
@@ -9,26 +9,28 @@ import (
 
 var id = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) < 1 {
+		login, found := ctx.UserOrParent("user")
+		if !found {
 			return fmt.Sprintf("Your ID is %d", ctx.SenderUserID), nil
 		}
-		id, found, err := helix.LoginToID(state.Http, ctx.Parameters[0])
+		id, found, err := helix.LoginToID(state.Http, login)
 		if err != nil {
 			return "", fmt.Errorf("Could not get ID: %w", err)
 		}
 		if found {
-			return fmt.Sprintf("ID of %s is %d", ctx.Parameters[0], id), nil
+			return fmt.Sprintf("ID of %s is %d", login, id), nil
 		} else {
-			return fmt.Sprintf("User %s not found.", ctx.Parameters[0]), nil
+			return fmt.Sprintf("User %s not found.", login), nil
 		}
 	},
 	Metadata: metadata{
 		Name:        "id",
-		Description: "Gets the Twitch user ID for you or another user.",
+		Description: "Gets the Twitch user ID for you, another user, or the author of the message you reply to.",
 		Cooldown:    1 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"id", "userid"},
-		Usage:       "#id [user]",
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Get the senders user ID:",
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/importer"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var importCmd = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		invalid := fmt.Sprintf("URL must be an upload from %s/import.", state.Config.PublicURL)
+		uploadURL, err := url.Parse(ctx.Args.String("url"))
+		if err != nil {
+			return invalid, nil
+		}
+		publicURL, err := url.Parse(state.Config.PublicURL)
+		if err != nil {
+			return "", fmt.Errorf("Invalid public URL in config: %w", err)
+		}
+		token, isUpload := strings.CutPrefix(uploadURL.Path, "/import/")
+		if uploadURL.Host != publicURL.Host || !isUpload {
+			return invalid, nil
+		}
+		data, found := importer.GetUpload(token)
+		if !found {
+			return "Upload not found. Uploads expire after 1 hour.", nil
+		}
+		result, err := importer.Parse(data)
+		if err != nil {
+			return fmt.Sprintf("Could not parse file: %s.", err), nil
+		}
+		imported, skipped, err := ImportEntries(state.DB, ctx.ChannelID, ctx.SenderUsername, result)
+		if err != nil {
+			return "", fmt.Errorf("Could not import commands: %w", err)
+		}
+		reply = fmt.Sprintf("Imported %d command%s.", imported, utils.PluraliseInt(imported))
+		if len(skipped) > 0 {
+			reasons := make([]string, 0, 5)
+			for _, s := range skipped[:min(len(skipped), 5)] {
+				reasons = append(reasons, fmt.Sprintf("%s (%s)", s.Name, s.Reason))
+			}
+			reply += fmt.Sprintf(" Skipped %d: %s", len(skipped), strings.Join(reasons, ", "))
+			if len(skipped) > 5 {
+				reply += ", ..."
+			}
+		}
+		return reply, nil
+	},
+	Metadata: metadata{
+		Name:                "import",
+		Description:         "Import static commands from Nightbot, StreamElements, Fossabot or another chat.",
+		ExtendedDescription: "Upload a JSON or CSV export on the bots website under /import, and run this command with the link you get. Exports of other chats can be downloaded from the page linked by #cmd list. Known variables like $(user), $(touser) and $(count) are translated. Commands using variables this bot does not support, or that conflict with existing commands, are skipped.",
+		Cooldown:            5 * time.Second,
+		MinimumRole:         RBroadcaster,
+		Aliases:             []string{"import"},
+		Args:                []argument{{Name: "url", Type: AString}},
+		Examples: []example{
+			{
+				Description: "Import an uploaded export:",
+				Command:     "#import https://bot.linneb.xyz/import/ABCDEF123456",
+				Response:    "@linneb, Imported 24 commands. Skipped 2: weather (unsupported variable $(urlfetch https://example.com)), so (unsupported variable $(twitch $(touser)))",
+			},
+		},
+	},
+}
+
+// ImportEntries creates static commands from parsed entries in a chat.
+// Entries that conflict with existing commands are skipped.
+func ImportEntries(db *pgxpool.Pool, chatID int, creator string, result importer.Result) (int, []importer.Skipped, error) {
+	skipped := result.Skipped
+	exists := func(name string) (bool, error) {
+		if _, found := Handler.GetCommandByAlias(name); found {
+			return true, nil
+		}
+		_, found, err := database.GetCommand(db, chatID, name)
+		return found, err
+	}
+
+	imported := 0
+	for _, entry := range result.Entries {
+		if len(entry.Name) >= 100 || len(entry.Reply) >= 400 {
+			skipped = append(skipped, importer.Skipped{Name: entry.Name, Reason: "too long"})
+			continue
+		}
+		if validateCommandName(entry.Name, "Command name") != "" {
+			skipped = append(skipped, importer.Skipped{Name: entry.Name, Reason: "invalid name"})
+			continue
+		}
+		found, err := exists(entry.Name)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if found {
+			skipped = append(skipped, importer.Skipped{Name: entry.Name, Reason: "already a command"})
+			continue
+		}
+		var aliases []string
+		for _, alias := range entry.Aliases {
+			if validateCommandName(alias, "Alias") != "" {
+				skipped = append(skipped, importer.Skipped{Name: alias, Reason: "invalid alias of " + entry.Name})
+				continue
+			}
+			found, err := exists(alias)
+			if err != nil {
+				return imported, skipped, err
+			}
+			if !found && alias != entry.Name {
+				aliases = append(aliases, alias)
+			}
+		}
+		command := models.Command{
+			ChatID:      chatID,
+			Name:        entry.Name,
+			Reply:       entry.Reply,
+			Cooldown:    int(max(entry.Cooldown, time.Second).Milliseconds()),
+			MinimumRole: roleNames[entry.Role],
+			Aliases:     aliases,
+			Enabled:     entry.Enabled,
+			CreatedBy:   creator,
+		}
+		err = database.CreateCommand(db, command)
+		if err != nil {
+			return imported, skipped, err
+		}
+		err = database.AddCommandHistory(db, models.CommandHistory{
+			ChatID: chatID,
+			Name:   command.Name,
+			Action: models.HistoryCreate,
+			Actor:  creator,
+			New:    &command,
+		})
+		if err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// ExportCommands returns all static commands in a chat in the format read by [importer.Parse].
+func ExportCommands(db *pgxpool.Pool, chat models.Chat) (importer.Export, error) {
+	cmds, err := database.GetCommands(db, chat.ChatID)
+	if err != nil {
+		return importer.Export{}, err
+	}
+	export := importer.Export{
+		Format:   importer.ExportFormat,
+		Chat:     chat.ChatName,
+		Commands: make([]importer.ExportedCommand, len(cmds)),
+	}
+	for i, c := range cmds {
+		role := "everyone"
+		for name, r := range roleNames {
+			if r == c.MinimumRole {
+				role = name
+			}
+		}
+		export.Commands[i] = importer.ExportedCommand{
+			Name:     c.Name,
+			Reply:    c.Reply,
+			Cooldown: c.Cooldown,
+			Role:     role,
+			Aliases:  c.Aliases,
+			Enabled:  c.Enabled,
+		}
+	}
+	return export, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bot/internal/database"
 	"bot/internal/helix"
 	"bot/internal/models"
+	"bot/internal/recent"
 	"fmt"
 	"strings"
 	"time"
@@ -19,10 +20,10 @@ var join = command{
 			if !ctx.IsAdmin {
 				return
 			}
-			if len(ctx.Parameters) < 1 {
+			if !ctx.Args.Has("channel") {
 				return fmt.Sprintf("Missing channel. Usage: %s <channel>", ctx.Command), nil
 			}
-			channel := strings.ToLower(ctx.Parameters[0])
+			channel := strings.ToLower(ctx.Args.String("channel"))
 
 			_, found, err := database.GetChatByName(state.DB, channel)
 			if err != nil {
@@ -53,7 +54,7 @@ var join = command{
 
 		if ctx.Invocation == "part" {
 			if ctx.IsBroadcaster {
-				if len(ctx.Parameters) < 1 || ctx.Parameters[0] != "DELETEME" {
+				if ctx.Args.String("channel") != "DELETEME" {
 					return fmt.Sprintf("This command will part this chat and DELETE all commands and live notifications PERMANENTLY. Use %s DELETEME to confirm.", ctx.Command), nil
 				}
 				err := database.DeleteChat(state.DB, models.Chat{
@@ -63,13 +64,15 @@ var join = command{
 				if err != nil {
 					return "", fmt.Errorf("Could not delete chat: %w", err)
 				}
+				Handler.InvalidateChat(ctx.ChannelID)
+				recent.Clear(ctx.ChannelID)
 				return "Parting channel. Until we meet again. :)", nil
 			}
 			if ctx.IsAdmin {
-				if len(ctx.Parameters) < 1 {
+				if !ctx.Args.Has("channel") {
 					return fmt.Sprintf("Missing channel. Usage: %s <channel>", ctx.Command), nil
 				}
-				channel := strings.ToLower(ctx.Parameters[0])
+				channel := strings.ToLower(ctx.Args.String("channel"))
 				chat, found, err := database.GetChatByName(state.DB, channel)
 				if err != nil {
 					return "", fmt.Errorf("Could not query database: %w", err)
@@ -81,6 +84,8 @@ var join = command{
 				if err != nil {
 					return "", fmt.Errorf("Could not delete from database: %w", err)
 				}
+				Handler.InvalidateChat(chat.ChatID)
+				recent.Clear(chat.ChatID)
 				state.IRC.Depart(channel)
 				return fmt.Sprintf("Leaving chat %s.", channel), nil
 			}
@@ -93,8 +98,12 @@ var join = command{
 		ExtendedDescription: "This command manages joined chats. Broadcasters can use it to remove the bot from their chat, while admins can use it to join/part any chat.",
 		Cooldown:            1 * time.Second,
 		MinimumRole:         RBroadcaster,
+		Whisper:             WAllowed,
 		Aliases:             []string{"join", "part"},
-		Usage:               "#<join|part> <channel>",
+		Args: []argument{
+			// Not AUser, since broadcasters confirm parting with "DELETEME"
+			{Name: "channel", Type: AString, Optional: true},
+		},
 		Examples: []example{
 			{
 				Description: "(Broadcaster) Remove bot from your chat:",
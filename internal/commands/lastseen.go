@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"bot/internal/chatlog"
+	"bot/internal/database"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+)
+
+var lastseen = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		login, userID, reply, err := logTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		entry, found, err := database.GetLastSeen(state.DB, userID)
+		if err != nil {
+			return "", fmt.Errorf("Could not get last seen message: %w", err)
+		}
+		if !found {
+			return fmt.Sprintf("%s has not been seen in any logged chat.", login), nil
+		}
+		chat, _, err := Handler.GetChat(state, entry.ChatID)
+		if err != nil {
+			return "", fmt.Errorf("Could not get chat: %w", err)
+		}
+		return fmt.Sprintf("%s was last seen %s ago in #%s: %s", entry.DisplayName, utils.PrettyDuration(time.Since(entry.SentAt)), chat.ChatName, entry.Text), nil
+	},
+	Metadata: metadata{
+		Name:        "lastseen",
+		Description: "Shows when and where a user last chatted, and what they said. Only chats with logging enabled are checked.",
+		Cooldown:    3 * time.Second,
+		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
+		Aliases:     []string{"lastseen", "ls"},
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Check when a user last chatted:",
+				Command:     "#lastseen forsen",
+				Response:    "@linneb, forsen was last seen 3 hours ago in #forsen: ZULUL",
+			},
+		},
+	},
+}
+
+// Resolves the user argument, or the author of the message being replied to, of a chat log command.
+// A non-empty reply is a user facing message explaining why the logs of the user can not be shown.
+func logTarget(state *models.State, ctx Context) (login string, userID int, reply string, err error) {
+	login, found := ctx.UserOrParent("user")
+	if !found {
+		return "", 0, "Specify a user, or reply to one of their messages.", nil
+	}
+	return checkLogTarget(
+		login,
+		func(login string) (int, bool, error) { return helix.LoginToID(state.Http, login) },
+		func(userID int, login string) (bool, error) { return chatlog.OptedOut(state, userID, login) },
+	)
+}
+
+// Resolves the user ID of login with lookup, and checks that the user has not opted out of chat logs.
+// A non-empty reply is a user facing message explaining why the user can not be looked up.
+func checkLogTarget(
+	login string,
+	lookup func(login string) (int, bool, error),
+	optedOut func(userID int, login string) (bool, error),
+) (string, int, string, error) {
+	userID, found, err := lookup(login)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("Could not get user id: %w", err)
+	}
+	if !found {
+		return "", 0, fmt.Sprintf("User %s not found.", login), nil
+	}
+	out, err := optedOut(userID, login)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("Could not get log opt-outs: %w", err)
+	}
+	if out {
+		return "", 0, fmt.Sprintf("%s has opted out of chat logs.", login), nil
+	}
+	return login, userID, "", nil
+}
+
+// Returns a reply explaining that logging is disabled, or an empty string if it is enabled in the chat of ctx.
+func loggingDisabled(state *models.State, ctx Context) (string, error) {
+	chat, _, err := Handler.GetChat(state, ctx.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("Could not get chat: %w", err)
+	}
+	if !chat.LogEnabled {
+		return fmt.Sprintf("Chat logs are not enabled in this chat. The broadcaster can enable them with %ssettings logs on.", ctx.Prefix), nil
+	}
+	return "", nil
+}
@@ -9,15 +9,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
-	"strings"
 	"time"
 )
 
 var latestEmotes = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
 		id := ctx.SenderUserID
-		if len(ctx.Parameters) > 0 {
-			login := strings.ToLower(ctx.Parameters[0])
+		if login, found := ctx.UserOrParent("channel"); found {
 			userid, found, err := helix.LoginToID(state.Http, login)
 			if err != nil {
 				return "", fmt.Errorf("Could not get user ID: %w", err)
@@ -76,11 +74,12 @@ var latestEmotes = command{
 	},
 	Metadata: metadata{
 		Name:        "latestEmotes",
-		Description: "Posts the 5 most recent 7TV emotes added to the current chat.",
+		Description: "Posts the 5 most recent 7TV emotes added to the current chat, or the channel of the replied-to user.",
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"latestemotes", "le"},
-		Usage:       "#le [channel]",
+		Args:        []argument{{Name: "channel", Type: AUser, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Get the 5 most recent emotes.",
@@ -5,17 +5,12 @@ import (
 	"bot/internal/models"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 )
 
 var live = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) < 1 {
-			return fmt.Sprintf("Missing argument: %s <channel>.", ctx.Command), nil
-		}
-
-		channel := strings.ToLower(ctx.Parameters[0])
+		channel := ctx.Args.String("channel")
 		stream, found, err := helix.GetStream(state.Http, channel)
 		if err != nil {
 			var ae *models.APIError
@@ -49,8 +44,9 @@ var live = command{
 		Description: "Sends information about a livestream.",
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"live", "stream"},
-		Usage:       "#live <channel>",
+		Args:        []argument{{Name: "channel", Type: AUser}},
 		Examples: []example{
 			{
 				Description: "Check if a channel is live:",
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"bot/internal/models"
+	"fmt"
+	"time"
+)
+
+var logs = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if reply, err := loggingDisabled(state, ctx); reply != "" || err != nil {
+			return reply, err
+		}
+		login, _, reply, err := logTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		return fmt.Sprintf("%s/channel/%s/user/%s", state.Config.PublicURL, ctx.ChannelName, login), nil
+	},
+	Metadata: metadata{
+		Name:        "logs",
+		Description: "Links to the chat logs of a user in the current chat.",
+		Cooldown:    3 * time.Second,
+		MinimumRole: RGeneric,
+		Aliases:     []string{"logs"},
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Get a link to the logs of a user:",
+				Command:     "#logs forsen",
+				Response:    "@linneb, https://bot.linneb.xyz/channel/forsen/user/forsen",
+			},
+		},
+	},
+}
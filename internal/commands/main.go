@@ -1,11 +1,14 @@
 package commands
 
 import (
+	"bot/internal/database"
 	"bot/internal/models"
 	"cmp"
+	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	irc "github.com/gempir/go-twitch-irc/v4"
@@ -18,6 +21,26 @@ const (
 	RAdmin
 )
 
+// Whisper modes, set by [metadata.Whisper].
+const (
+	// Only usable in chat
+	WNever = iota
+	// Usable in whispers. Replies in chat that don't fit in a single message are whispered instead.
+	WAllowed
+	// Usable in whispers, and replies in chat are always whispered
+	WAlways
+)
+
+// Role names that can be used in chat, for example by the override command.
+// Admin is left out on purpose, since admins are set in the config file.
+var roleNames = map[string]int{
+	"everyone":    RGeneric,
+	"mod":         RMod,
+	"broadcaster": RBroadcaster,
+}
+
+var roleChoices = []string{"everyone", "mod", "broadcaster"}
+
 // Command execution context
 type Context struct {
 	// Sender information
@@ -37,14 +60,26 @@ type Context struct {
 	Command string
 	// Command alias used excluding prefix
 	Invocation string
+	// Command prefix of the chat
+	Prefix string
+	// Message being replied to, empty if the message is not a reply
+	ParentMessageID   string
+	ParentMessage     string
+	ParentUserID      int
+	ParentUsername    string
+	ParentDisplayname string
 	// Moderator
 	IsMod bool
 	// Broadcaster
 	IsBroadcaster bool
 	// Admin
 	IsAdmin bool
+	// Sent as a whisper. Whispers have no channel, so ChannelID is 0 and ChannelName is empty
+	IsWhisper bool
 	// Role
 	Role int
+	// Parsed arguments, flags and subcommand, as declared in the commands metadata
+	Args arguments
 }
 
 func NewContext(state *models.State, msg irc.PrivateMessage) (context Context, err error) {
@@ -56,8 +91,12 @@ func NewContext(state *models.State, msg irc.PrivateMessage) (context Context, e
 	if err != nil {
 		return context, err
 	}
+	Prefix, err := Handler.GetPrefix(state, ChannelID)
+	if err != nil {
+		return context, err
+	}
 	Arguments := strings.Fields(msg.Message)
-	Invocation := strings.TrimPrefix(Arguments[0], state.Config.Prefix)
+	Invocation := strings.TrimPrefix(Arguments[0], Prefix)
 
 	isAdmin := slices.Contains(state.Config.Admins, msg.User.Name)
 	role := RGeneric
@@ -71,7 +110,7 @@ func NewContext(state *models.State, msg irc.PrivateMessage) (context Context, e
 		role = RAdmin
 	}
 
-	return Context{
+	context = Context{
 		SenderUserID:      SenderUserID,
 		SenderUsername:    msg.User.Name,
 		SenderDisplayname: msg.User.DisplayName,
@@ -82,13 +121,84 @@ func NewContext(state *models.State, msg irc.PrivateMessage) (context Context, e
 		Parameters:        Arguments[1:],
 		Command:           strings.ToLower(Arguments[0]),
 		Invocation:        strings.ToLower(Invocation),
+		Prefix:            Prefix,
 		IsMod:             msg.User.IsMod,
 		IsBroadcaster:     msg.User.IsBroadcaster,
 		IsAdmin:           isAdmin,
 		Role:              role,
+	}
+	if msg.Reply != nil {
+		context.ParentUserID, err = strconv.Atoi(msg.Reply.ParentUserID)
+		if err != nil {
+			return context, err
+		}
+		context.ParentMessageID = msg.Reply.ParentMsgID
+		context.ParentMessage = msg.Reply.ParentMsgBody
+		context.ParentUsername = msg.Reply.ParentUserLogin
+		context.ParentDisplayname = msg.Reply.ParentDisplayName
+	}
+	return context, nil
+}
+
+// NewWhisperContext creates a context for a whispered command. The prefix is optional in whispers.
+func NewWhisperContext(state *models.State, msg irc.WhisperMessage) (context Context, err error) {
+	SenderUserID, err := strconv.Atoi(msg.User.ID)
+	if err != nil {
+		return context, err
+	}
+	Arguments := strings.Fields(msg.Message)
+	if len(Arguments) == 0 {
+		return context, fmt.Errorf("Empty whisper")
+	}
+	Invocation := strings.TrimPrefix(Arguments[0], state.Config.Prefix)
+
+	isAdmin := slices.Contains(state.Config.Admins, msg.User.Name)
+	role := RGeneric
+	if isAdmin {
+		role = RAdmin
+	}
+
+	return Context{
+		SenderUserID:      SenderUserID,
+		SenderUsername:    msg.User.Name,
+		SenderDisplayname: msg.User.DisplayName,
+		Message:           msg.Message,
+		Arguments:         Arguments,
+		Parameters:        Arguments[1:],
+		Command:           state.Config.Prefix + strings.ToLower(Invocation),
+		Invocation:        strings.ToLower(Invocation),
+		Prefix:            state.Config.Prefix,
+		IsAdmin:           isAdmin,
+		IsWhisper:         true,
+		Role:              role,
 	}, nil
 }
 
+// Returns the user passed as the argument name, falling back to the author of the message being replied to.
+// Returns false if neither is available.
+func (c Context) UserOrParent(name string) (string, bool) {
+	if c.Args.Has(name) {
+		return c.Args.String(name), true
+	}
+	if c.ParentUsername != "" {
+		return c.ParentUsername, true
+	}
+	return "", false
+}
+
+// StripReplyMention removes the @mention Twitch adds to the start of replies.
+func StripReplyMention(msg irc.PrivateMessage) string {
+	if msg.Reply == nil {
+		return msg.Message
+	}
+	first, rest, _ := strings.Cut(msg.Message, " ")
+	mention := strings.ToLower(strings.TrimPrefix(first, "@"))
+	if strings.HasPrefix(first, "@") && (mention == strings.ToLower(msg.Reply.ParentDisplayName) || mention == msg.Reply.ParentUserLogin) {
+		return strings.TrimLeft(rest, " ")
+	}
+	return msg.Message
+}
+
 type command struct {
 	Run      func(state *models.State, ctx Context) (reply string, err error)
 	Metadata metadata
@@ -102,13 +212,34 @@ type metadata struct {
 	ExtendedDescription string
 	Cooldown            time.Duration
 	MinimumRole         int
-	Aliases             []string
-	Usage               string
-	Examples            []example
+	// Whether the command can be used in and reply with whispers, one of the W* constants
+	Whisper int
+	Aliases []string
+	// Positional arguments. Ignored if Subcommands is set.
+	Args []argument
+	// Flags available to the command and all subcommands.
+	Flags       []flag
+	Subcommands []subcommand
+	Examples    []example
 }
 
 func (m metadata) PrettyRole() string {
-	switch m.MinimumRole {
+	return PrettyRole(m.MinimumRole)
+}
+
+// Returns a copy of the metadata with the cooldown and minimum role of a chats override applied.
+func (m metadata) WithOverride(override models.CommandOverride) metadata {
+	if override.Cooldown != nil {
+		m.Cooldown = time.Duration(*override.Cooldown) * time.Millisecond
+	}
+	if override.MinimumRole != nil {
+		m.MinimumRole = *override.MinimumRole
+	}
+	return m
+}
+
+func PrettyRole(role int) string {
+	switch role {
 	case RAdmin:
 		return "Admin"
 	case RBroadcaster:
@@ -134,6 +265,9 @@ type handler struct {
 	//     }
 	// }
 	Cooldowns map[int]map[string]time.Time
+	// Cached chats by ID, see [handler.GetChat]
+	chats   map[int]models.Chat
+	chatsMu sync.Mutex
 }
 
 // Attempts to get a command based on an alias.
@@ -186,26 +320,82 @@ func (h *handler) SetCooldown(id int, name string) {
 	h.Cooldowns[id][name] = time.Now()
 }
 
+// Cached wrapper around [database.GetChat].
+func (h *handler) GetChat(state *models.State, id int) (chat models.Chat, found bool, err error) {
+	h.chatsMu.Lock()
+	defer h.chatsMu.Unlock()
+	if chat, found := h.chats[id]; found {
+		return chat, true, nil
+	}
+	chat, found, err = database.GetChat(state.DB, id)
+	if err != nil || !found {
+		return chat, found, err
+	}
+	h.chats[id] = chat
+	return chat, true, nil
+}
+
+// Removes a chat from the cache. Must be called after a chat is modified.
+func (h *handler) InvalidateChat(id int) {
+	h.chatsMu.Lock()
+	defer h.chatsMu.Unlock()
+	delete(h.chats, id)
+}
+
+// Returns the command prefix of a chat, falling back to the prefix from the config file.
+func (h *handler) GetPrefix(state *models.State, id int) (string, error) {
+	chat, _, err := h.GetChat(state, id)
+	if err != nil {
+		return "", err
+	}
+	if chat.Prefix != "" {
+		return chat.Prefix, nil
+	}
+	return state.Config.Prefix, nil
+}
+
 var Handler *handler
 
 func init() {
 	Handler = &handler{
 		Commands: []command{
+			afkCmd,
+			ban,
 			banned,
 			cmd,
+			counter,
+			filter,
+			firstmessage,
 			followers,
 			help,
 			id,
+			importCmd,
 			join,
+			lastseen,
 			latestEmotes,
 			live,
+			logs,
 			notify,
+			nuke,
+			optout,
+			override,
+			permit,
 			ping,
 			randomEmote,
+			remind,
+			remindersCmd,
+			rl,
+			search,
+			settings,
 			subscribe,
+			timer,
 			title,
 			thumbnail,
+			timeout,
+			trigger,
+			unban,
 		},
 		Cooldowns: make(map[int]map[string]time.Time),
+		chats:     make(map[int]models.Chat),
 	}
 }
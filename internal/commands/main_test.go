@@ -1,16 +1,26 @@
 package commands
 
 import (
+	"errors"
 	"os"
+	"slices"
 	"testing"
 )
 
+// Files in this directory that do not define a command
+var supportFiles = []string{"main.go", "main_test.go", "args.go", "args_test.go"}
+
 func TestLoadedCommands(t *testing.T) {
 	files, err := os.ReadDir(".")
 	if err != nil {
 		t.Errorf("Could not read command directory: %s", err)
 	}
-	cmdFiles := len(files) - 2 // Subtract 2 for "main.go" and "main_test.go"
+	cmdFiles := 0
+	for _, file := range files {
+		if !slices.Contains(supportFiles, file.Name()) {
+			cmdFiles++
+		}
+	}
 	if len(Handler.Commands) != cmdFiles {
 		t.Errorf("Missing command file: Expected %d; Loaded %d", cmdFiles, len(Handler.Commands))
 	}
@@ -25,7 +35,6 @@ func TestVerifyMetadata(t *testing.T) {
 			{"description", c.Metadata.Description != ""},
 			{"cooldown", c.Metadata.Cooldown != 0},
 			{"aliases", len(c.Metadata.Aliases) > 0},
-			{"usage", c.Metadata.Usage != ""},
 			{"minimumRole", c.Metadata.MinimumRole != 0},
 			{"examples", len(c.Metadata.Examples) > 0},
 		}
@@ -34,5 +43,67 @@ func TestVerifyMetadata(t *testing.T) {
 				t.Errorf("Missing metadata field %s in command %s", v.fieldName, c.Metadata.Name)
 			}
 		}
+		verifyArguments(t, c.Metadata.Name, c.Metadata.Args)
+		for _, s := range c.Metadata.Subcommands {
+			verifyArguments(t, c.Metadata.Name+" "+s.Name, s.Args)
+		}
+	}
+}
+
+func verifyArguments(t *testing.T, name string, args []argument) {
+	optional := false
+	for i, a := range args {
+		if a.Type == AText && i != len(args)-1 {
+			t.Errorf("Text argument %s is not the last argument in command %s", a.Name, name)
+		}
+		if a.Type == AEnum && len(a.Choices) == 0 {
+			t.Errorf("Enum argument %s has no choices in command %s", a.Name, name)
+		}
+		if a.Type == ABool {
+			t.Errorf("Argument %s in command %s uses ABool, which is only valid for flags", a.Name, name)
+		}
+		if a.FromReply && a.Type != AUser {
+			t.Errorf("Argument %s in command %s uses FromReply, which is only valid for AUser", a.Name, name)
+		}
+		if optional && !a.Optional {
+			t.Errorf("Required argument %s follows an optional argument in command %s", a.Name, name)
+		}
+		optional = optional || a.Optional
+	}
+}
+
+func TestLogTarget(t *testing.T) {
+	_, _, reply, err := logTarget(nil, Context{})
+	if reply != "Specify a user, or reply to one of their messages." || err != nil {
+		t.Errorf("Expected a reply asking for a user; Got %q, %v", reply, err)
+	}
+
+	users := map[string]int{"forsen": 1, "linneb": 2}
+	lookup := func(login string) (int, bool, error) {
+		if login == "error" {
+			return 0, false, errors.New("helix is down")
+		}
+		id, found := users[login]
+		return id, found, nil
+	}
+	optedOut := func(userID int, login string) (bool, error) {
+		return userID == 2, nil
+	}
+	tests := []struct {
+		login  string
+		userID int
+		reply  string
+		err    bool
+	}{
+		{"forsen", 1, "", false},
+		{"linneb", 0, "linneb has opted out of chat logs.", false},
+		{"nobody", 0, "User nobody not found.", false},
+		{"error", 0, "", true},
+	}
+	for _, test := range tests {
+		_, userID, reply, err := checkLogTarget(test.login, lookup, optedOut)
+		if userID != test.userID || reply != test.reply || (err != nil) != test.err {
+			t.Errorf("Login %s: Expected %d, %q, error %t; Got %d, %q, %v", test.login, test.userID, test.reply, test.err, userID, reply, err)
+		}
 	}
 }
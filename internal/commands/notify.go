@@ -6,7 +6,6 @@ import (
 	"bot/internal/models"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/LinneB/twitchwh"
@@ -14,17 +13,8 @@ import (
 
 var notify = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) < 1 {
-			return fmt.Sprintf("No subcommand provided. Usage: %s <add|remove> <channel>.", ctx.Command), nil
-		}
-		if len(ctx.Parameters) < 2 {
-			return fmt.Sprintf("No channel provided. Usage: %s <add|remove> <channel>.", ctx.Command), nil
-		}
-		subcommand := ctx.Parameters[0]
-		channel := strings.ToLower(ctx.Parameters[1])
-		if subcommand != "add" && subcommand != "remove" {
-			return fmt.Sprintf("Invalid subcommand. Usage: %s <add|remove> <channel>.", ctx.Command), nil
-		}
+		subcommand := ctx.Args.Subcommand
+		channel := ctx.Args.String("channel")
 
 		id, found, err := helix.LoginToID(state.Http, channel)
 		if err != nil {
@@ -40,7 +30,7 @@ var notify = command{
 				return "", fmt.Errorf("Could not get subscription: %w", err)
 			}
 			if found {
-				return fmt.Sprintf("Channel is already added to live notifications. Use %ssubscribe to be pinged when they go live.", state.Config.Prefix), nil
+				return fmt.Sprintf("Channel is already added to live notifications. Use %ssubscribe to be pinged when they go live.", ctx.Prefix), nil
 			}
 
 			err = database.CreateSubscription(state.DB, models.Subscription{
@@ -56,7 +46,7 @@ var notify = command{
 			})
 			var duplicate *twitchwh.DuplicateSubscriptionError
 			if err == nil || errors.As(err, &duplicate) {
-				return fmt.Sprintf("Added %s to notifications! Use %ssubscribe to be pinged when they go live.", channel, state.Config.Prefix), nil
+				return fmt.Sprintf("Added %s to notifications! Use %ssubscribe to be pinged when they go live.", channel, ctx.Prefix), nil
 			}
 			return "", fmt.Errorf("Could not add eventsub subscription: %w", err)
 		}
@@ -98,7 +88,18 @@ var notify = command{
 		Cooldown:            3 * time.Second,
 		MinimumRole:         RMod,
 		Aliases:             []string{"notify", "notif", "livenotif"},
-		Usage:               "#notify <add|remove> <channel>",
+		Subcommands: []subcommand{
+			{
+				Name:        "add",
+				Description: "Add a channel to live notifications.",
+				Args:        []argument{{Name: "channel", Type: AUser}},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a channel and all of its subscribers from live notifications.",
+				Args:        []argument{{Name: "channel", Type: AUser}},
+			},
+		},
 		Examples: []example{
 			{
 				Description: "Add a channel to the chats live notifications:",
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/queue"
+	"bot/internal/recent"
+	"bot/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Longest lookback allowed. Only the latest [recent.Size] messages are kept anyway.
+	maxNukeLookback = 30 * time.Minute
+	// Delay between timeouts, keeps a nuke well below the Helix rate limit of 800 requests per minute
+	nukeInterval = 100 * time.Millisecond
+	// Wait before retrying a timeout that was rate limited
+	nukeBackoff = 10 * time.Second
+)
+
+var (
+	nukesMu sync.Mutex
+	// Chats with a nuke in progress
+	nukes = make(map[int]bool)
+)
+
+var nuke = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		phrase := ctx.Args.String("phrase")
+		matches, err := nukeMatcher(phrase)
+		if err != nil {
+			return fmt.Sprintf("Invalid regex: %s", err), nil
+		}
+		lookback := ctx.Args.Duration("lookback")
+		if lookback <= 0 || lookback > maxNukeLookback {
+			return fmt.Sprintf("Lookback has to be between 1 second and %s.", utils.PrettyDuration(maxNukeLookback)), nil
+		}
+		duration := ctx.Args.Duration("timeout")
+		if duration < time.Second || duration > maxTimeout {
+			return fmt.Sprintf("Timeout has to be between 1 second and %s.", utils.PrettyDuration(maxTimeout)), nil
+		}
+
+		targets := nukeTargets(recent.Since(ctx.ChannelID, time.Now().Add(-lookback)), matches, ctx.SenderUserID, state.BotUserID)
+		if len(targets) == 0 {
+			return "No messages matched.", nil
+		}
+		if ctx.Args.Bool("dry-run") {
+			return fmt.Sprintf("Dry run: %d user%s would be timed out for %s.", len(targets), utils.PluraliseInt(len(targets)), utils.PrettyDuration(duration)), nil
+		}
+
+		nukesMu.Lock()
+		if nukes[ctx.ChannelID] {
+			nukesMu.Unlock()
+			return "A nuke is already running in this chat.", nil
+		}
+		nukes[ctx.ChannelID] = true
+		nukesMu.Unlock()
+
+		reason := fmt.Sprintf("Nuked by %s", ctx.SenderUsername)
+		go runNuke(state, ctx.ChannelID, ctx.ChannelName, targets, duration, reason)
+		return fmt.Sprintf("Timing out %d user%s for %s.", len(targets), utils.PluraliseInt(len(targets)), utils.PrettyDuration(duration)), nil
+	},
+	Metadata: metadata{
+		Name:                "nuke",
+		Description:         "Times out every user who said a phrase within the lookback window. Phrases wrapped in slashes are regular expressions.",
+		ExtendedDescription: "Phrases are matched case insensitively, use quotes for phrases with spaces. Only the latest 1000 messages of each chat are remembered, and mods, the broadcaster and messages removed by filters are never nuked. Use --dry-run to see how many users would be timed out first. The bot has to be a moderator.",
+		Cooldown:            5 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"nuke"},
+		Args: []argument{
+			{Name: "phrase", Type: AQuoted},
+			{Name: "lookback", Type: ADuration},
+			{Name: "timeout", Type: ADuration},
+		},
+		Flags: []flag{
+			{Name: "dry-run", Type: ABool, Description: "Only count the users that would be timed out."},
+		},
+		Examples: []example{
+			{
+				Description: "Check how many users spammed a copypasta in the last 5 minutes:",
+				Command:     "#nuke \"join my discord\" 5m 10m --dry-run",
+				Response:    "@linneb, Dry run: 42 users would be timed out for 10 minutes.",
+			},
+			{
+				Description: "Time out everyone who posted a matching link in the last minute for an hour:",
+				Command:     "#nuke /bit\\.ly\\/\\w+/ 1m 1h",
+				Response:    "@linneb, Timing out 7 users for 1 hour.",
+			},
+		},
+	},
+}
+
+// Returns a function matching messages against a phrase, or a regex if the phrase is wrapped in slashes.
+func nukeMatcher(phrase string) (func(string) bool, error) {
+	if len(phrase) > 2 && strings.HasPrefix(phrase, "/") && strings.HasSuffix(phrase, "/") {
+		regex, err := regexp.Compile("(?i)" + phrase[1:len(phrase)-1])
+		if err != nil {
+			return nil, err
+		}
+		return regex.MatchString, nil
+	}
+	phrase = strings.ToLower(phrase)
+	return func(text string) bool {
+		return strings.Contains(strings.ToLower(text), phrase)
+	}, nil
+}
+
+// Returns one matching message per user, leaving out the invoker and the bot.
+func nukeTargets(messages []recent.Message, matches func(string) bool, invokerID int, botID int) []recent.Message {
+	seen := make(map[int]bool)
+	var targets []recent.Message
+	for _, m := range messages {
+		if seen[m.UserID] || m.UserID == invokerID || m.UserID == botID || !matches(m.Text) {
+			continue
+		}
+		seen[m.UserID] = true
+		targets = append(targets, m)
+	}
+	return targets
+}
+
+// Times out targets one at a time, and posts a summary in chat when done.
+func runNuke(state *models.State, channelID int, channel string, targets []recent.Message, duration time.Duration, reason string) {
+	defer func() {
+		nukesMu.Lock()
+		delete(nukes, channelID)
+		nukesMu.Unlock()
+	}()
+	ticker := time.NewTicker(nukeInterval)
+	defer ticker.Stop()
+
+	timedOut := 0
+	for _, target := range targets {
+		<-ticker.C
+		err := helix.TimeoutUser(state.Http, channelID, state.BotUserID, target.UserID, duration, reason)
+		if errors.Is(err, helix.ErrRateLimited) {
+			time.Sleep(nukeBackoff)
+			err = helix.TimeoutUser(state.Http, channelID, state.BotUserID, target.UserID, duration, reason)
+		}
+		switch {
+		case err == nil:
+			timedOut++
+		case errors.Is(err, helix.ErrTargetIsModerator), errors.Is(err, helix.ErrAlreadyBanned):
+			// Modded or banned since sending the message
+		case errors.Is(err, helix.ErrNotModerator), errors.Is(err, helix.ErrMissingScope), errors.Is(err, helix.ErrUnauthorized):
+			reply, _ := moderationReply(err, target.Login)
+			state.Queue.Say(channel, "Nuke stopped: "+reply, queue.PriorityHigh)
+			return
+		default:
+			log.Printf("Could not time out %s in %s: %s", target.Login, channel, err)
+		}
+	}
+	state.Queue.Say(channel, fmt.Sprintf("Nuke done, timed out %d of %d user%s.", timedOut, len(targets), utils.PluraliseInt(len(targets))), queue.PriorityNormal)
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bot/internal/chatlog"
+	"bot/internal/database"
+	"bot/internal/models"
+	"fmt"
+	"time"
+)
+
+var optout = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if ctx.Invocation == "optin" {
+			removed, err := database.RemoveLogOptOut(state.DB, ctx.SenderUserID)
+			if err != nil {
+				return "", fmt.Errorf("Could not remove opt-out: %w", err)
+			}
+			chatlog.InvalidateOptOuts()
+			if !removed {
+				return "You have not opted out of chat logs.", nil
+			}
+			return "You opted back in to chat logs. Your old messages are visible again.", nil
+		}
+		err = database.AddLogOptOut(state.DB, ctx.SenderUserID, ctx.SenderUsername)
+		if err != nil {
+			return "", fmt.Errorf("Could not add opt-out: %w", err)
+		}
+		chatlog.InvalidateOptOuts()
+		return fmt.Sprintf("You opted out of chat logs. Your messages are no longer logged, and existing logs are hidden. Use %soptin to undo.", ctx.Prefix), nil
+	},
+	Metadata: metadata{
+		Name:                "optout",
+		Description:         "Opt out of chat logging in every chat, or back in with optin.",
+		ExtendedDescription: "While opted out, your messages, bans and timeouts are not logged, and existing logs of you are hidden from the log pages and commands. Opting back in shows the existing logs again.",
+		Cooldown:            5 * time.Second,
+		MinimumRole:         RGeneric,
+		Whisper:             WAllowed,
+		Aliases:             []string{"optout", "optin"},
+		Examples: []example{
+			{
+				Description: "Stop logging your messages:",
+				Command:     "#optout",
+				Response:    "@linneb, You opted out of chat logs. Your messages are no longer logged, and existing logs are hidden. Use #optin to undo.",
+			},
+			{
+				Description: "Start logging your messages again:",
+				Command:     "#optin",
+				Response:    "@linneb, You opted back in to chat logs. Your old messages are visible again.",
+			},
+		},
+	},
+}
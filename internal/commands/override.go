@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var override = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		name := strings.ToLower(ctx.Args.String("command"))
+		command, found := Handler.GetCommandByName(name)
+		if !found {
+			command, found = Handler.GetCommandByAlias(name)
+			if !found {
+				return "Command name/alias not found.", nil
+			}
+		}
+		name = command.Metadata.Name
+		if name == "override" {
+			return "The override command can not be overridden.", nil
+		}
+
+		current, found, err := database.GetCommandOverride(state.DB, ctx.ChannelID, name)
+		if err != nil {
+			return "", fmt.Errorf("Could not get override: %w", err)
+		}
+		if !found {
+			current = models.CommandOverride{
+				ChatID:  ctx.ChannelID,
+				Command: name,
+				Enabled: true,
+			}
+		}
+
+		effective := command.Metadata.WithOverride(current)
+		if ctx.Args.Subcommand != "show" && max(command.Metadata.MinimumRole, effective.MinimumRole) > ctx.Role {
+			return fmt.Sprintf("You can not change %s, it requires the %s role.", name, PrettyRole(max(command.Metadata.MinimumRole, effective.MinimumRole))), nil
+		}
+
+		switch ctx.Args.Subcommand {
+		case "show":
+			status := "enabled"
+			if !current.Enabled {
+				status = "disabled"
+			}
+			return fmt.Sprintf("%s is %s in this chat. Cooldown: %s. Minimum role: %s.", name, status, effective.Cooldown, effective.PrettyRole()), nil
+		case "enable", "disable":
+			// Broadcasters must always be able to remove the bot with #part
+			if name == "join" && ctx.Args.Subcommand == "disable" {
+				return "The join/part command can not be disabled.", nil
+			}
+			current.Enabled = ctx.Args.Subcommand == "enable"
+		case "cooldown":
+			cooldown := ctx.Args.Duration("cooldown")
+			if cooldown < time.Second {
+				return "Cooldown must be at least 1 second.", nil
+			}
+			ms := int(cooldown.Milliseconds())
+			current.Cooldown = &ms
+		case "role":
+			role := roleNames[ctx.Args.String("role")]
+			if role < command.Metadata.MinimumRole {
+				return fmt.Sprintf("The minimum role of %s can not be lowered below %s.", name, command.Metadata.PrettyRole()), nil
+			}
+			if role > ctx.Role {
+				return fmt.Sprintf("You can not raise the minimum role of %s above your own role.", name), nil
+			}
+			current.MinimumRole = &role
+		case "reset":
+			if !found {
+				return fmt.Sprintf("%s does not have an override in this chat.", name), nil
+			}
+			err = database.DeleteCommandOverride(state.DB, ctx.ChannelID, name)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete override: %w", err)
+			}
+			return fmt.Sprintf("Reset %s to its default settings.", name), nil
+		}
+
+		err = database.SetCommandOverride(state.DB, current)
+		if err != nil {
+			return "", fmt.Errorf("Could not set override: %w", err)
+		}
+		return fmt.Sprintf("Updated %s for this chat.", name), nil
+	},
+	Metadata: metadata{
+		Name:                "override",
+		Description:         "Enable/disable commands or change their cooldown and minimum role in the current chat.",
+		ExtendedDescription: "Overrides only apply to the chat they are set in. The minimum role of a command can be raised up to your own role, but never lowered below its default. Commands that require a higher role than yours can not be changed, and join/part can not be disabled.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"override"},
+		Subcommands: []subcommand{
+			{
+				Name:        "show",
+				Description: "Show the current settings of a command.",
+				Args:        []argument{{Name: "command", Type: AString}},
+			},
+			{
+				Name:        "enable",
+				Description: "Enable a command.",
+				Args:        []argument{{Name: "command", Type: AString}},
+			},
+			{
+				Name:        "disable",
+				Description: "Disable a command.",
+				Args:        []argument{{Name: "command", Type: AString}},
+			},
+			{
+				Name:        "cooldown",
+				Description: "Change the per-user cooldown of a command.",
+				Args: []argument{
+					{Name: "command", Type: AString},
+					{Name: "cooldown", Type: ADuration},
+				},
+			},
+			{
+				Name:        "role",
+				Description: "Change the minimum role required to use a command.",
+				Args: []argument{
+					{Name: "command", Type: AString},
+					{Name: "role", Type: AEnum, Choices: roleChoices},
+				},
+			},
+			{
+				Name:        "reset",
+				Description: "Remove all overrides of a command.",
+				Args:        []argument{{Name: "command", Type: AString}},
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Disable a command in the current chat:",
+				Command:     "#override disable banned",
+				Response:    "@linneb, Updated banned for this chat.",
+			},
+			{
+				Description: "Make a command mod-only:",
+				Command:     "#override role live mod",
+				Response:    "@linneb, Updated live for this chat.",
+			},
+			{
+				Description: "Show the current settings of a command:",
+				Command:     "#override show live",
+				Response:    "@linneb, live is enabled in this chat. Cooldown: 3s. Minimum role: Mod.",
+			},
+			{
+				Description: "Go back to the default settings:",
+				Command:     "#override reset live",
+				Response:    "@linneb, Reset live to its default settings.",
+			},
+		},
+	},
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"bot/internal/filters"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+)
+
+var permit = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		login, found := ctx.UserOrParent("user")
+		if !found {
+			return "Specify a user, or reply to one of their messages.", nil
+		}
+		filters.Permit(ctx.ChannelID, login)
+		return fmt.Sprintf("%s can post links for the next %s.", login, utils.PrettyDuration(filters.PermitDuration)), nil
+	},
+	Metadata: metadata{
+		Name:        "permit",
+		Description: "Allow a user to post links for 60 seconds, bypassing link filters.",
+		Cooldown:    1 * time.Second,
+		MinimumRole: RMod,
+		Aliases:     []string{"permit"},
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Let a user post a link:",
+				Command:     "#permit forsen",
+				Response:    "@linneb, forsen can post links for the next 1 minute.",
+			},
+		},
+	},
+}
@@ -29,20 +29,25 @@ var ping = command{
 			memory = fmt.Sprintf("%.0f KB", kb)
 		}
 
-		return fmt.Sprintf("Pong! Bot has been up for %s. Database ping is %s. Heap usage: %s.", uptime, dbPing, memory), nil
+		queued := 0
+		for _, depth := range state.Queue.Depth() {
+			queued += depth
+		}
+
+		return fmt.Sprintf("Pong! Bot has been up for %s. Database ping is %s. Heap usage: %s. Queued messages: %d.", uptime, dbPing, memory, queued), nil
 	},
 	Metadata: metadata{
 		Name:        "ping",
 		Description: "Returns uptime and other information.",
 		Cooldown:    1 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"ping", "uptime"},
-		Usage:       "#ping",
 		Examples: []example{
 			{
 				Description: "Check that the bot is alive:",
 				Command:     "#ping",
-				Response:    "@linneb, Pong! Bot has been up for 9 seconds. Database ping is 12 μs. Heap usage: 1.5 MB.",
+				Response:    "@linneb, Pong! Bot has been up for 9 seconds. Database ping is 12 μs. Heap usage: 1.5 MB. Queued messages: 0.",
 			},
 			{
 				Description: "If the bot is offline, it wont respond!",
@@ -8,15 +8,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	"strings"
 	"time"
 )
 
 var randomEmote = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
 		id := ctx.ChannelID
-		if len(ctx.Parameters) > 0 {
-			login := strings.ToLower(ctx.Parameters[0])
+		if ctx.Args.Has("channel") {
+			login := ctx.Args.String("channel")
 			userid, found, err := helix.LoginToID(state.Http, login)
 			if err != nil {
 				return "", fmt.Errorf("Could not get user ID: %w", err)
@@ -80,7 +79,7 @@ var randomEmote = command{
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
 		Aliases:     []string{"randomemotes", "re"},
-		Usage:       "#re [channel]",
+		Args:        []argument{{Name: "channel", Type: AUser, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Post 5 random emotes:",
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/reminders"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Pending reminders a user can have created at once
+	maxReminders = 10
+	// Longest reminder message, limited by the database
+	maxReminderLength = 400
+)
+
+var remind = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		delay, message, timed := reminders.ParseDelay(ctx.Args.String("text"))
+		if message == "" {
+			return "Missing reminder text.", nil
+		}
+		if utf8.RuneCountInString(message) > maxReminderLength {
+			return fmt.Sprintf("Reminder is too long (max %d characters).", maxReminderLength), nil
+		}
+		if timed && (delay < time.Second || delay > reminders.MaxDelay) {
+			return fmt.Sprintf("Time has to be between 1 second and %s.", utils.PrettyDuration(reminders.MaxDelay)), nil
+		}
+
+		login := ctx.Args.String("user")
+		targetID := ctx.SenderUserID
+		if login == "me" || login == ctx.SenderUsername {
+			if !timed {
+				return fmt.Sprintf("Set a time to remind yourself, like %s me in 1h %s", ctx.Command, message), nil
+			}
+			login = ctx.SenderUsername
+		} else {
+			var found bool
+			targetID, found, err = helix.LoginToID(state.Http, login)
+			if err != nil {
+				return "", fmt.Errorf("Could not get user id: %w", err)
+			}
+			if !found {
+				return fmt.Sprintf("User %s not found.", login), nil
+			}
+		}
+
+		count, err := database.CountRemindersByAuthor(state.DB, ctx.SenderUserID)
+		if err != nil {
+			return "", fmt.Errorf("Could not count reminders: %w", err)
+		}
+		if count >= maxReminders {
+			return fmt.Sprintf("You already have %d pending reminders. Cancel one with %sreminders cancel <id> first.", count, ctx.Prefix), nil
+		}
+
+		r := models.Reminder{
+			ChatID:      ctx.ChannelID,
+			AuthorID:    ctx.SenderUserID,
+			AuthorLogin: ctx.SenderUsername,
+			TargetID:    targetID,
+			TargetLogin: login,
+			Message:     message,
+		}
+		if timed {
+			dueAt := time.Now().Add(delay)
+			r.DueAt = &dueAt
+		}
+		r, err = database.CreateReminder(state.DB, r)
+		if err != nil {
+			return "", fmt.Errorf("Could not create reminder: %w", err)
+		}
+		reminders.Add(state, r)
+
+		who := login
+		if targetID == ctx.SenderUserID {
+			who = "you"
+		}
+		if !timed {
+			return fmt.Sprintf("I will remind %s the next time they chat (ID %d).", who, r.ID), nil
+		}
+		return fmt.Sprintf("I will remind %s in %s (ID %d).", who, utils.PrettyDuration(delay), r.ID), nil
+	},
+	Metadata: metadata{
+		Name:                "remind",
+		Description:         "Reminds a user after some time, or the next time they chat. Use \"me\" to remind yourself.",
+		ExtendedDescription: "Timed reminders are posted in the chat they were created in, while reminders without a time are posted in whatever chat the user talks in next. Times use the same format as durations elsewhere, like 30m or 1h30m, or a number of days like 3d. Each user can have 10 pending reminders, see the reminders command to list and cancel them.",
+		Cooldown:            3 * time.Second,
+		MinimumRole:         RGeneric,
+		Aliases:             []string{"remind", "remindme"},
+		Args: []argument{
+			{Name: "user", Type: AUser},
+			{Name: "text", Type: AText},
+		},
+		Examples: []example{
+			{
+				Description: "Remind yourself in 2 hours:",
+				Command:     "#remind me in 2h take out the trash",
+				Response:    "@linneb, I will remind you in 2 hours (ID 12).",
+			},
+			{
+				Description: "Remind someone the next time they chat:",
+				Command:     "#remind forsen play more minecraft",
+				Response:    "@linneb, I will remind forsen the next time they chat (ID 13).",
+			},
+		},
+	},
+}
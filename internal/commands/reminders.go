@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var remindersCmd = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		switch ctx.Args.Subcommand {
+		case "list":
+			pending, err := database.GetRemindersByAuthor(state.DB, ctx.SenderUserID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get reminders: %w", err)
+			}
+			if len(pending) == 0 {
+				return "You have no pending reminders.", nil
+			}
+			list := make([]string, len(pending))
+			for i, r := range pending {
+				when := "on their next message"
+				if r.DueAt != nil {
+					when = "in " + utils.PrettyDuration(time.Until(*r.DueAt))
+				}
+				list[i] = fmt.Sprintf("%d: %s %s: %s", r.ID, r.TargetLogin, when, r.Message)
+			}
+			return strings.Join(list, " | "), nil
+		case "cancel":
+			cancelled, err := database.CancelReminder(state.DB, ctx.SenderUserID, ctx.Args.Int("id"))
+			if err != nil {
+				return "", fmt.Errorf("Could not cancel reminder: %w", err)
+			}
+			if !cancelled {
+				return fmt.Sprintf("You have no reminder with ID %d.", ctx.Args.Int("id")), nil
+			}
+			return fmt.Sprintf("Cancelled reminder %d.", ctx.Args.Int("id")), nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:        "reminders",
+		Description: "List and cancel the reminders you created.",
+		Cooldown:    2 * time.Second,
+		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
+		Aliases:     []string{"reminders"},
+		Subcommands: []subcommand{
+			{
+				Name:        "list",
+				Description: "List your pending reminders.",
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel a pending reminder by ID.",
+				Args:        []argument{{Name: "id", Type: AInt}},
+			},
+		},
+		Examples: []example{
+			{
+				Description: "List your reminders:",
+				Command:     "#reminders list",
+				Response:    "@linneb, 12: linneb in 2 hours: take out the trash | 13: forsen on their next message: play more minecraft",
+			},
+			{
+				Description: "Cancel a reminder:",
+				Command:     "#reminders cancel 13",
+				Response:    "@linneb, Cancelled reminder 13.",
+			},
+		},
+	},
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+)
+
+var rl = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if reply, err := loggingDisabled(state, ctx); reply != "" || err != nil {
+			return reply, err
+		}
+		userID := 0
+		if ctx.Args.Has("user") || ctx.ParentUsername != "" {
+			_, userID, reply, err = logTarget(state, ctx)
+			if reply != "" || err != nil {
+				return reply, err
+			}
+		}
+		entry, found, err := database.GetRandomLine(state.DB, ctx.ChannelID, userID)
+		if err != nil {
+			return "", fmt.Errorf("Could not get random line: %w", err)
+		}
+		if !found {
+			return "No logged messages found.", nil
+		}
+		return fmt.Sprintf("(%s ago) %s: %s", utils.PrettyDuration(time.Since(entry.SentAt)), entry.DisplayName, entry.Text), nil
+	},
+	Metadata: metadata{
+		Name:        "rl",
+		Description: "Sends a random logged message from the current chat, optionally from a specific user.",
+		Cooldown:    3 * time.Second,
+		MinimumRole: RGeneric,
+		Aliases:     []string{"rl", "randomline"},
+		Args:        []argument{{Name: "user", Type: AUser, Optional: true}},
+		Examples: []example{
+			{
+				Description: "Get a random line from chat:",
+				Command:     "#rl",
+				Response:    "@linneb, (3 weeks ago) forsen: ZULUL",
+			},
+			{
+				Description: "Get a random line from a user:",
+				Command:     "#rl forsen",
+				Response:    "@linneb, (5 days ago) forsen: hi chat",
+			},
+		},
+	},
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"bot/internal/chatlog"
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var search = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if reply, err := loggingDisabled(state, ctx); reply != "" || err != nil {
+			return reply, err
+		}
+		phrase := ctx.Args.String("phrase")
+		count, err := database.CountChatLogSearch(state.DB, models.ChatLogSearch{ChatID: ctx.ChannelID, Query: phrase})
+		if err != nil {
+			return "", fmt.Errorf("Could not count search results: %w", err)
+		}
+		token := chatlog.SignToken(state.Config.WebSecret, ctx.ChannelID, time.Now().Add(chatlog.TokenDuration))
+		query := url.Values{"q": {phrase}, "token": {token}}
+		return fmt.Sprintf("%d result%s: %s/chat/%s/search?%s", count, utils.PluraliseInt(count), state.Config.PublicURL, ctx.ChannelName, query.Encode()), nil
+	},
+	Metadata: metadata{
+		Name:                "search",
+		Description:         "Searches the chat logs of the current chat, and whispers a link to the results.",
+		ExtendedDescription: "The link gives access to the search page of the chat for 24 hours, where logs can also be filtered by user and date, so it is whispered instead of posted in chat. Use \"quotes\" for phrases, or between words, and -word to exclude a word.",
+		Cooldown:            5 * time.Second,
+		MinimumRole:         RMod,
+		Whisper:             WAlways,
+		Aliases:             []string{"search"},
+		Args:                []argument{{Name: "phrase", Type: AText}},
+		Examples: []example{
+			{
+				Description: "Search for a phrase:",
+				Command:     "#search \"what game\"",
+				Response:    "12 results: https://bot.linneb.xyz/chat/linneb/search?q=%22what+game%22&token=...",
+			},
+		},
+	},
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Longest time chat logs can be kept for, in days
+const maxLogRetention = 365
+
+var settings = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		switch ctx.Args.Subcommand {
+		case "show":
+			chat, _, err := Handler.GetChat(state, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get chat: %w", err)
+			}
+			logs := "off"
+			if chat.LogEnabled {
+				logs = fmt.Sprintf("on, kept for %d day%s", chat.LogRetention, utils.PluraliseInt(chat.LogRetention))
+			}
+			notices := "off"
+			if chat.AFKNotice {
+				notices = "on"
+			}
+			return fmt.Sprintf("Prefix: %s. Replies: %s. Logs: %s. AFK notices: %s.", ctx.Prefix, chat.ReplyMode, logs, notices), nil
+		case "prefix":
+			prefix := ctx.Args.String("prefix")
+			if strings.ToLower(prefix) == "reset" {
+				prefix = ""
+			}
+			if len(prefix) > 10 {
+				return "Prefix is too long! (max 10 characters).", nil
+			}
+			if strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, ".") {
+				return "Prefix can not start with / or . since those are used by Twitch.", nil
+			}
+			if strings.ContainsFunc(prefix, unicode.IsSpace) {
+				return "Prefix can not contain whitespace.", nil
+			}
+			err = database.SetChatPrefix(state.DB, ctx.ChannelID, prefix)
+			if err != nil {
+				return "", fmt.Errorf("Could not set prefix: %w", err)
+			}
+			Handler.InvalidateChat(ctx.ChannelID)
+			if prefix == "" {
+				prefix = state.Config.Prefix
+			}
+			return fmt.Sprintf("Prefix changed to %s. Use %ssettings prefix reset to go back to the default.", prefix, prefix), nil
+		case "replies":
+			mode := ctx.Args.String("mode")
+			err = database.SetChatReplyMode(state.DB, ctx.ChannelID, mode)
+			if err != nil {
+				return "", fmt.Errorf("Could not set reply mode: %w", err)
+			}
+			Handler.InvalidateChat(ctx.ChannelID)
+			return fmt.Sprintf("Replies changed to %s.", mode), nil
+		case "logs":
+			chat, _, err := Handler.GetChat(state, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get chat: %w", err)
+			}
+			enabled := ctx.Args.String("state") == "on"
+			retention := chat.LogRetention
+			if ctx.Args.Has("days") {
+				retention = ctx.Args.Int("days")
+			}
+			if retention < 1 || retention > maxLogRetention {
+				return fmt.Sprintf("Logs can be kept for 1 to %d days.", maxLogRetention), nil
+			}
+			err = database.SetChatLogging(state.DB, ctx.ChannelID, enabled, retention)
+			if err != nil {
+				return "", fmt.Errorf("Could not set logging: %w", err)
+			}
+			Handler.InvalidateChat(ctx.ChannelID)
+			if !enabled {
+				return fmt.Sprintf("Chat logging disabled. Existing logs are deleted after %d day%s.", retention, utils.PluraliseInt(retention)), nil
+			}
+			return fmt.Sprintf("Chat logging enabled, logs are kept for %d day%s.", retention, utils.PluraliseInt(retention)), nil
+		case "afk":
+			enabled := ctx.Args.String("state") == "on"
+			err = database.SetChatAFKNotice(state.DB, ctx.ChannelID, enabled)
+			if err != nil {
+				return "", fmt.Errorf("Could not set AFK notices: %w", err)
+			}
+			Handler.InvalidateChat(ctx.ChannelID)
+			if enabled {
+				return "Users who mention someone who is AFK will be told that they are away.", nil
+			}
+			return "AFK notices disabled.", nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:        "settings",
+		Description: "Change the bots settings for the current chat.",
+		Cooldown:    1 * time.Second,
+		MinimumRole: RBroadcaster,
+		Aliases:     []string{"settings", "set"},
+		Subcommands: []subcommand{
+			{
+				Name:        "show",
+				Description: "Show the current settings.",
+			},
+			{
+				Name:        "prefix",
+				Description: "Change the command prefix. Use \"reset\" to go back to the default.",
+				Args:        []argument{{Name: "prefix", Type: AString}},
+			},
+			{
+				Name:        "replies",
+				Description: "Change how the bot replies to commands: as a Twitch reply thread, with an @mention (default), or without either.",
+				Args: []argument{
+					{Name: "mode", Type: AEnum, Choices: []string{models.ReplyThread, models.ReplyMention, models.ReplyBare}},
+				},
+			},
+			{
+				Name:        "logs",
+				Description: "Turn logging of chat messages on or off, and optionally set how many days logs are kept (30 by default).",
+				Args: []argument{
+					{Name: "state", Type: AEnum, Choices: []string{"on", "off"}},
+					{Name: "days", Type: AInt, Optional: true},
+				},
+			},
+			{
+				Name:        "afk",
+				Description: "Turn on or off telling users who @mention someone who is AFK that they are away.",
+				Args:        []argument{{Name: "state", Type: AEnum, Choices: []string{"on", "off"}}},
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Use ! as the command prefix in your chat:",
+				Command:     "#settings prefix !",
+				Response:    "@linneb, Prefix changed to !. Use !settings prefix reset to go back to the default.",
+			},
+			{
+				Description: "Go back to the default prefix:",
+				Command:     "!settings prefix reset",
+				Response:    "@linneb, Prefix changed to #. Use #settings prefix reset to go back to the default.",
+			},
+			{
+				Description: "Reply to commands in a thread instead of mentioning the user:",
+				Command:     "#settings replies thread",
+				Response:    "Replies changed to thread.",
+			},
+			{
+				Description: "Log chat messages and keep them for two weeks:",
+				Command:     "#settings logs on 14",
+				Response:    "@linneb, Chat logging enabled, logs are kept for 14 days.",
+			},
+		},
+	},
+}
@@ -4,22 +4,18 @@ import (
 	"bot/internal/database"
 	"bot/internal/models"
 	"fmt"
-	"strings"
 	"time"
 )
 
 var subscribe = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
-		if len(ctx.Parameters) < 1 {
-			return fmt.Sprintf("Missing channel. Usage: %s <channel>", ctx.Command), nil
-		}
-		channel := strings.ToLower(ctx.Parameters[0])
+		channel := ctx.Args.String("channel")
 		sub, found, err := database.GetSubscriptionByName(state.DB, ctx.ChannelID, channel)
 		if err != nil {
 			return "", fmt.Errorf("Could not get subscription: %w", err)
 		}
 		if !found {
-			return fmt.Sprintf("This chat is not subscribed to %s. Moderators can use %snotify to add/remove channels.", channel, state.Config.Prefix), nil
+			return fmt.Sprintf("This chat is not subscribed to %s. Moderators can use %snotify to add/remove channels.", channel, ctx.Prefix), nil
 		}
 
 		isSubbed, err := database.IsUserSubscribed(state.DB, ctx.SenderUsername, sub.SubscriptionID)
@@ -47,7 +43,7 @@ var subscribe = command{
 		Cooldown:    1 * time.Second,
 		MinimumRole: RGeneric,
 		Aliases:     []string{"subscribe"},
-		Usage:       "#subscribe <channel>",
+		Args:        []argument{{Name: "channel", Type: AUser}},
 		Examples: []example{
 			{
 				Description: "Subscribe to a channel (this requires that a mod has added them to live notifications):",
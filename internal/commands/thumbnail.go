@@ -11,10 +11,7 @@ import (
 
 var thumbnail = command{
 	Run: func(state *models.State, ctx Context) (string, error) {
-		if len(ctx.Parameters) < 1 {
-			return fmt.Sprintf("Missing channel. Usage: %s <channel>", ctx.Command), nil
-		}
-		channel := ctx.Parameters[0]
+		channel := ctx.Args.String("channel")
 		stream, found, err := helix.GetStream(state.Http, channel)
 		if err != nil {
 			var ae *models.APIError
@@ -37,8 +34,9 @@ var thumbnail = command{
 		Description: "Get the thumbnail of a stream.",
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
+		Whisper:     WAllowed,
 		Aliases:     []string{"thumbnail"},
-		Usage:       "#thumbnail <channel>",
+		Args:        []argument{{Name: "channel", Type: AUser}},
 		Examples: []example{{
 			Description: "Get the thumbnail of forsen's stream:",
 			Command:     "#thumbnail forsen",
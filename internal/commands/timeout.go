@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Minute
+	// Longest timeout allowed by Twitch
+	maxTimeout = 14 * 24 * time.Hour
+)
+
+var timeout = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		duration := defaultTimeout
+		if ctx.Args.Has("duration") {
+			duration = ctx.Args.Duration("duration")
+		}
+		if duration < time.Second || duration > maxTimeout {
+			return fmt.Sprintf("Duration has to be between 1 second and %s.", utils.PrettyDuration(maxTimeout)), nil
+		}
+		login, userID, reply, err := moderationTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		err = helix.TimeoutUser(state.Http, ctx.ChannelID, state.BotUserID, userID, duration, ctx.Args.String("reason"))
+		if reply, ok := moderationReply(err, login); ok {
+			return reply, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Could not timeout user: %w", err)
+		}
+		return fmt.Sprintf("Timed out %s for %s.", login, utils.PrettyDuration(duration)), nil
+	},
+	Metadata: metadata{
+		Name:        "timeout",
+		Description: "Times out a user, 10 minutes by default. Reply to a message to time out its author. The bot has to be a moderator.",
+		Cooldown:    1 * time.Second,
+		MinimumRole: RMod,
+		Aliases:     []string{"timeout", "to"},
+		Args: []argument{
+			{Name: "user", Type: AUser, Optional: true, FromReply: true},
+			{Name: "duration", Type: ADuration, Optional: true},
+			{Name: "reason", Type: AText, Optional: true},
+		},
+		Examples: []example{
+			{
+				Description: "Time out a user for 10 minutes:",
+				Command:     "#timeout forsen",
+				Response:    "@linneb, Timed out forsen for 10 minutes.",
+			},
+			{
+				Description: "Time out a user for an hour with a reason:",
+				Command:     "#timeout forsen 1h spamming",
+				Response:    "@linneb, Timed out forsen for 1 hour.",
+			},
+			{
+				Description: "Time out the author of a message by replying to it:",
+				Command:     "#timeout 1h spamming",
+				Response:    "@linneb, Timed out forsen for 1 hour.",
+			},
+		},
+	},
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxTimers = 20
+
+var timer = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		if ctx.Args.Subcommand == "list" {
+			timers, err := database.GetTimers(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get timers: %w", err)
+			}
+			if len(timers) == 0 {
+				return "This chat does not have any timers.", nil
+			}
+			descriptions := make([]string, len(timers))
+			for i, t := range timers {
+				descriptions[i] = describeTimer(t)
+			}
+			return fmt.Sprintf("Timers: %s.", strings.Join(descriptions, ", ")), nil
+		}
+
+		name := strings.ToLower(ctx.Args.String("name"))
+		existing, found, err := database.GetTimer(state.DB, ctx.ChannelID, name)
+		if err != nil {
+			return "", fmt.Errorf("Could not get timer: %w", err)
+		}
+
+		switch ctx.Args.Subcommand {
+		case "add":
+			if found {
+				return fmt.Sprintf("Timer %s already exists.", name), nil
+			}
+			if len(name) > 50 {
+				return "Timer name is too long! (max 50 characters).", nil
+			}
+			timers, err := database.GetTimers(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get timers: %w", err)
+			}
+			if len(timers) >= maxTimers {
+				return fmt.Sprintf("This chat already has the maximum of %d timers.", maxTimers), nil
+			}
+			t := models.Timer{
+				ChatID:      ctx.ChannelID,
+				Name:        name,
+				Message:     ctx.Args.String("message"),
+				Interval:    int(ctx.Args.Duration("interval").Minutes()),
+				LiveOnly:    ctx.Args.Bool("live"),
+				MinMessages: ctx.Args.Int("min-messages"),
+			}
+			if invalid := validateTimer(t); invalid != "" {
+				return invalid, nil
+			}
+			err = database.CreateTimer(state.DB, t)
+			if err != nil {
+				return "", fmt.Errorf("Could not create timer: %w", err)
+			}
+			return fmt.Sprintf("Added timer %s.", describeTimer(t)), nil
+
+		case "remove":
+			if !found {
+				return fmt.Sprintf("Timer %s not found.", name), nil
+			}
+			err = database.DeleteTimer(state.DB, existing)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete timer: %w", err)
+			}
+			return fmt.Sprintf("Removed timer %s.", name), nil
+
+		case "edit":
+			if !found {
+				return fmt.Sprintf("Timer %s not found.", name), nil
+			}
+			if ctx.Args.Has("message") {
+				existing.Message = ctx.Args.String("message")
+			}
+			if ctx.Args.Has("interval") {
+				existing.Interval = int(ctx.Args.Duration("interval").Minutes())
+			}
+			if ctx.Args.Has("live") {
+				existing.LiveOnly = ctx.Args.String("live") == "on"
+			}
+			if ctx.Args.Has("min-messages") {
+				existing.MinMessages = ctx.Args.Int("min-messages")
+			}
+			if invalid := validateTimer(existing); invalid != "" {
+				return invalid, nil
+			}
+			err = database.UpdateTimer(state.DB, existing)
+			if err != nil {
+				return "", fmt.Errorf("Could not update timer: %w", err)
+			}
+			return fmt.Sprintf("Edited timer %s.", describeTimer(existing)), nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:                "timer",
+		Description:         "Add/remove/edit messages that are posted periodically.",
+		ExtendedDescription: "Timers post a message in the chat every N minutes (minimum 5). Timers can be limited to only post while the channel is live, and only if a minimum number of chat messages have been sent since the last post.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"timer", "timers"},
+		Subcommands: []subcommand{
+			{
+				Name:        "add",
+				Description: "Add a new timer.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "interval", Type: ADuration},
+					{Name: "message", Type: AText},
+				},
+				Flags: []flag{
+					{Name: "live", Type: ABool, Description: "Only post while the channel is live."},
+					{Name: "min-messages", Type: AInt, Description: "Minimum number of chat messages since the last post."},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a timer.",
+				Args:        []argument{{Name: "name", Type: AString}},
+			},
+			{
+				Name:        "list",
+				Description: "List all timers in the current chat.",
+			},
+			{
+				Name:        "edit",
+				Description: "Change the message or settings of a timer.",
+				Args: []argument{
+					{Name: "name", Type: AString},
+					{Name: "message", Type: AText, Optional: true},
+				},
+				Flags: []flag{
+					{Name: "interval", Type: ADuration, Description: "How often the timer is posted."},
+					{Name: "live", Type: AEnum, Choices: []string{"on", "off"}, Description: "Only post while the channel is live."},
+					{Name: "min-messages", Type: AInt, Description: "Minimum number of chat messages since the last post."},
+				},
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Post a message every 30 minutes while the channel is live:",
+				Command:     "#timer add socials 30m Follow me on twitter! --live",
+				Response:    "@linneb, Added timer socials (every 30m, live only).",
+			},
+			{
+				Description: "Only post if at least 10 messages were sent since the last post:",
+				Command:     "#timer edit socials --min-messages 10",
+				Response:    "@linneb, Edited timer socials (every 30m, live only, 10 messages).",
+			},
+			{
+				Description: "List timers:",
+				Command:     "#timer list",
+				Response:    "@linneb, Timers: socials (every 30m, live only, 10 messages).",
+			},
+			{
+				Description: "Remove the timer:",
+				Command:     "#timer remove socials",
+				Response:    "@linneb, Removed timer socials.",
+			},
+		},
+	},
+}
+
+// Returns a human readable error if the timer is invalid.
+func validateTimer(t models.Timer) string {
+	if t.Interval < 5 {
+		return "Interval must be at least 5 minutes."
+	}
+	if t.Message == "" {
+		return "Timer message can not be empty."
+	}
+	if len(t.Message) >= 400 {
+		return "Timer message is too long! (max 400 characters)."
+	}
+	if t.MinMessages < 0 {
+		return "Minimum messages can not be negative."
+	}
+	return ""
+}
+
+func describeTimer(t models.Timer) string {
+	details := []string{fmt.Sprintf("every %dm", t.Interval)}
+	if t.LiveOnly {
+		details = append(details, "live only")
+	}
+	if t.MinMessages > 0 {
+		details = append(details, fmt.Sprintf("%d messages", t.MinMessages))
+	}
+	return fmt.Sprintf("%s (%s)", t.Name, strings.Join(details, ", "))
+}
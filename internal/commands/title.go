@@ -6,15 +6,13 @@ import (
 	"bot/internal/models"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 )
 
 var title = command{
 	Run: func(state *models.State, ctx Context) (reply string, err error) {
 		id := ctx.ChannelID
-		if len(ctx.Parameters) > 0 {
-			login := strings.ToLower(ctx.Parameters[0])
+		if login, found := ctx.UserOrParent("channel"); found {
 			userid, found, err := helix.LoginToID(state.Http, login)
 			if err != nil {
 				return "", fmt.Errorf("Could not get user ID: %w", err)
@@ -63,11 +61,11 @@ var title = command{
 	},
 	Metadata: metadata{
 		Name:        "title",
-		Description: "Gets the title of a channel. Defaults to current chat.",
+		Description: "Gets the title of a channel. Defaults to the replied-to user, or the current chat.",
 		Cooldown:    3 * time.Second,
 		MinimumRole: RGeneric,
 		Aliases:     []string{"title"},
-		Usage:       "#title [channel]",
+		Args:        []argument{{Name: "channel", Type: AUser, Optional: true}},
 		Examples: []example{
 			{
 				Description: "Get the current chats title:",
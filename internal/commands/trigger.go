@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/triggers"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxTriggers = 50
+
+var trigger = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		switch ctx.Args.Subcommand {
+		case "add":
+			existing, err := database.GetTriggers(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get triggers: %w", err)
+			}
+			if len(existing) >= maxTriggers {
+				return fmt.Sprintf("This chat already has the maximum of %d triggers.", maxTriggers), nil
+			}
+			cooldown := 30 * time.Second
+			if ctx.Args.Has("cooldown") {
+				cooldown = ctx.Args.Duration("cooldown")
+			}
+			if cooldown < 5*time.Second {
+				return "Cooldown must be at least 5 seconds.", nil
+			}
+			t := models.Trigger{
+				ChatID:          ctx.ChannelID,
+				Pattern:         ctx.Args.String("pattern"),
+				Regex:           ctx.Args.Bool("regex"),
+				CaseInsensitive: ctx.Args.Bool("ignore-case"),
+				Cooldown:        int(cooldown.Milliseconds()),
+				Reply:           ctx.Args.String("reply"),
+			}
+			if strings.TrimSpace(t.Pattern) == "" {
+				return "Triggers need a pattern.", nil
+			}
+			if len(t.Pattern) > 200 {
+				return "Pattern is too long! (max 200 characters).", nil
+			}
+			if len(t.Reply) >= 400 {
+				return "Trigger reply is too long! (max 400 characters).", nil
+			}
+			if err := triggers.Validate(t); err != nil {
+				return fmt.Sprintf("Invalid regex: %s.", err), nil
+			}
+			id, err := database.CreateTrigger(state.DB, t)
+			if err != nil {
+				return "", fmt.Errorf("Could not create trigger: %w", err)
+			}
+			triggers.Invalidate(ctx.ChannelID)
+			return fmt.Sprintf("Added trigger #%d.", id), nil
+
+		case "remove":
+			t, found, err := database.GetTrigger(state.DB, ctx.ChannelID, ctx.Args.Int("id"))
+			if err != nil {
+				return "", fmt.Errorf("Could not get trigger: %w", err)
+			}
+			if !found {
+				return fmt.Sprintf("Trigger #%d not found.", ctx.Args.Int("id")), nil
+			}
+			err = database.DeleteTrigger(state.DB, t)
+			if err != nil {
+				return "", fmt.Errorf("Could not delete trigger: %w", err)
+			}
+			triggers.Invalidate(ctx.ChannelID)
+			return fmt.Sprintf("Removed trigger #%d.", t.ID), nil
+
+		case "list":
+			existing, err := database.GetTriggers(state.DB, ctx.ChannelID)
+			if err != nil {
+				return "", fmt.Errorf("Could not get triggers: %w", err)
+			}
+			if len(existing) == 0 {
+				return "This chat does not have any triggers.", nil
+			}
+			descriptions := make([]string, len(existing))
+			for i, t := range existing {
+				description := fmt.Sprintf("#%d \"%s\"", t.ID, t.Pattern)
+				if t.Regex {
+					description += " (regex)"
+				}
+				descriptions[i] = description
+			}
+			return fmt.Sprintf("Triggers: %s.", strings.Join(descriptions, ", ")), nil
+		}
+		return "", fmt.Errorf("This error is impossible and will never happen")
+	},
+	Metadata: metadata{
+		Name:                "trigger",
+		Description:         "Add/remove replies to messages matching a keyword or regex.",
+		ExtendedDescription: "Triggers reply to any chat message containing a pattern, without the command prefix. Patterns with spaces must be wrapped in double quotes. Regular expressions use Go's RE2 syntax. Every trigger has its own cooldown shared by the whole chat, which defaults to 30 seconds.",
+		Cooldown:            1 * time.Second,
+		MinimumRole:         RMod,
+		Aliases:             []string{"trigger", "triggers"},
+		Subcommands: []subcommand{
+			{
+				Name:        "add",
+				Description: "Add a new trigger.",
+				Args: []argument{
+					{Name: "pattern", Type: AQuoted},
+					{Name: "reply", Type: AText},
+				},
+				Flags: []flag{
+					{Name: "regex", Type: ABool, Description: "Treat the pattern as a regular expression."},
+					{Name: "ignore-case", Type: ABool, Description: "Match regardless of upper/lower case."},
+					{Name: "cooldown", Type: ADuration, Description: "Cooldown of the trigger, minimum 5 seconds."},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a trigger by ID.",
+				Args:        []argument{{Name: "id", Type: AInt}},
+			},
+			{
+				Name:        "list",
+				Description: "List all triggers in the current chat.",
+			},
+		},
+		Examples: []example{
+			{
+				Description: "Answer a common question:",
+				Command:     "#trigger add \"what game\" It's Minecraft! --ignore-case",
+				Response:    "@linneb, Added trigger #1.",
+			},
+			{
+				Description: "The bot will reply to any message containing the pattern:",
+				Command:     "What game is this?",
+				Response:    "@someone, It's Minecraft!",
+			},
+			{
+				Description: "Use a regex with a 1 minute cooldown:",
+				Command:     "#trigger add ^!(discord|dc)\\b https://discord.gg/example --regex --cooldown 1m",
+				Response:    "@linneb, Added trigger #2.",
+			},
+			{
+				Description: "Remove a trigger:",
+				Command:     "#trigger remove 1",
+				Response:    "@linneb, Removed trigger #1.",
+			},
+		},
+	},
+}
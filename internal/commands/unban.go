@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"bot/internal/helix"
+	"bot/internal/models"
+	"fmt"
+	"time"
+)
+
+var unban = command{
+	Run: func(state *models.State, ctx Context) (reply string, err error) {
+		login, userID, reply, err := moderationTarget(state, ctx)
+		if reply != "" || err != nil {
+			return reply, err
+		}
+		err = helix.UnbanUser(state.Http, ctx.ChannelID, state.BotUserID, userID)
+		if reply, ok := moderationReply(err, login); ok {
+			return reply, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Could not unban user: %w", err)
+		}
+		return fmt.Sprintf("Unbanned %s.", login), nil
+	},
+	Metadata: metadata{
+		Name:        "unban",
+		Description: "Removes a ban or timeout from a user. The bot has to be a moderator.",
+		Cooldown:    1 * time.Second,
+		MinimumRole: RMod,
+		Aliases:     []string{"unban", "untimeout"},
+		Args:        []argument{{Name: "user", Type: AUser}},
+		Examples: []example{
+			{
+				Description: "Unban a user:",
+				Command:     "#unban forsen",
+				Response:    "@linneb, Unbanned forsen.",
+			},
+		},
+	},
+}
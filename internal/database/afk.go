@@ -0,0 +1,40 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const afkColumns = "userid, login, kind, reason, since"
+
+func GetAFKs(db *pgxpool.Pool) ([]models.AFK, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+afkColumns+" FROM afk")
+	afks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.AFK])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return afks, nil
+}
+
+// Mark a user as AFK, replacing any previous status.
+func SetAFK(db *pgxpool.Pool, afk models.AFK) error {
+	_, err := db.Exec(context.Background(), `
+INSERT INTO afk (`+afkColumns+`) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (userid) DO UPDATE SET login = EXCLUDED.login, kind = EXCLUDED.kind, reason = EXCLUDED.reason, since = EXCLUDED.since`,
+		afk.UserID, afk.Login, afk.Kind, afk.Reason, afk.Since)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+func DeleteAFK(db *pgxpool.Pool, userid int) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM afk WHERE userid = $1", userid)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Layout of the day in the name of day partitions, like chatlog_123_20240131.
+const partitionDayLayout = "20060102"
+
+func chatLogPartition(chatid int) string {
+	return fmt.Sprintf("chatlog_%d", chatid)
+}
+
+func chatLogDayPartition(chatid int, day time.Time) string {
+	return fmt.Sprintf("chatlog_%d_%s", chatid, day.UTC().Format(partitionDayLayout))
+}
+
+// Creates the partitions for a chat and UTC day if they don't exist.
+// Must be called before inserting chat logs sent on that day.
+func EnsureChatLogPartition(db *pgxpool.Pool, chatid int, day time.Time) error {
+	start := day.UTC().Truncate(24 * time.Hour)
+	chatPartition := pgx.Identifier{chatLogPartition(chatid)}.Sanitize()
+	dayPartition := pgx.Identifier{chatLogDayPartition(chatid, start)}.Sanitize()
+	// Partition bounds can't be query parameters, the values are formatted by us so they are safe
+	_, err := db.Exec(context.Background(), fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s PARTITION OF chatlog FOR VALUES IN (%d) PARTITION BY RANGE (sent_at);
+CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s');`,
+		chatPartition, chatid,
+		dayPartition, chatPartition, start.Format(time.RFC3339), start.Add(24*time.Hour).Format(time.RFC3339),
+	))
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Inserts chat logs in a single COPY. The partitions of every entry must exist, see [EnsureChatLogPartition].
+func InsertChatLogs(db *pgxpool.Pool, entries []models.ChatLogEntry) error {
+	_, err := db.CopyFrom(context.Background(),
+		pgx.Identifier{"chatlog"},
+		[]string{"chatid", "sent_at", "kind", "message_id", "userid", "login", "display_name", "text", "raw"},
+		pgx.CopyFromSlice(len(entries), func(i int) ([]any, error) {
+			e := entries[i]
+			return []any{e.ChatID, e.SentAt, e.Kind, e.MessageID, e.UserID, e.Login, e.DisplayName, e.Text, e.Raw}, nil
+		}),
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+const chatLogColumns = "chatid, sent_at, kind, message_id, userid, login, display_name, text, raw"
+
+// Leaves out messages from and moderation of users who opted out.
+// CLEARMSG has no user ID, so the login is checked too.
+const withoutOptOuts = "userid NOT IN (SELECT userid FROM log_optouts) AND login NOT IN (SELECT login FROM log_optouts)"
+
+// Get the chat logs of a chat sent between from and to, oldest first.
+func GetChatLogs(db *pgxpool.Pool, chatid int, from time.Time, to time.Time) ([]models.ChatLogEntry, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+chatLogColumns+" FROM chatlog WHERE chatid = $1 AND sent_at >= $2 AND sent_at < $3 AND "+withoutOptOuts+" ORDER BY sent_at", chatid, from, to)
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ChatLogEntry])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return entries, nil
+}
+
+// Get the chat logs of a user in a chat sent between from and to, oldest first.
+// Includes bans, timeouts and deleted messages of the user.
+func GetUserChatLogs(db *pgxpool.Pool, chatid int, userid int, login string, from time.Time, to time.Time) ([]models.ChatLogEntry, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+chatLogColumns+" FROM chatlog WHERE chatid = $1 AND (userid = $2 OR (userid = 0 AND login = $3)) AND sent_at >= $4 AND sent_at < $5 AND "+withoutOptOuts+" ORDER BY sent_at", chatid, userid, login, from, to)
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ChatLogEntry])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return entries, nil
+}
+
+// Get the latest chat message of a user in any chat with logging enabled.
+func GetLastSeen(db *pgxpool.Pool, userid int) (models.ChatLogEntry, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+chatLogColumns+" FROM chatlog WHERE userid = $1 AND kind = 'PRIVMSG' AND chatid IN (SELECT chatid FROM chats WHERE log_enabled) AND "+withoutOptOuts+" ORDER BY sent_at DESC LIMIT 1", userid)
+	return collectChatLog(rows)
+}
+
+// Get the first logged chat message of a user in a chat.
+func GetFirstMessage(db *pgxpool.Pool, chatid int, userid int) (models.ChatLogEntry, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+chatLogColumns+" FROM chatlog WHERE chatid = $1 AND userid = $2 AND kind = 'PRIVMSG' AND "+withoutOptOuts+" ORDER BY sent_at LIMIT 1", chatid, userid)
+	return collectChatLog(rows)
+}
+
+// Get a random chat message in a chat, from a specific user unless userid is 0.
+//
+// Picking a random row with ORDER BY random() reads every row, so instead a random point in time
+// between the first and last message is picked, and the first message after it is returned.
+// The point is picked before the second query, so both queries can use the sent_at indexes.
+// Messages after long gaps are more likely to be picked.
+func GetRandomLine(db *pgxpool.Pool, chatid int, userid int) (models.ChatLogEntry, bool, error) {
+	filter, args := randomLineFilter(chatid, userid)
+	var first, last *time.Time
+	err := db.QueryRow(context.Background(), "SELECT min(sent_at), max(sent_at) FROM chatlog WHERE "+filter, args...).Scan(&first, &last)
+	if err != nil {
+		return models.ChatLogEntry{}, false, models.NewDatabaseError(err)
+	}
+	if first == nil || last == nil {
+		return models.ChatLogEntry{}, false, nil
+	}
+	args = append(args, randomTime(*first, *last, rand.Float64()))
+	rows, _ := db.Query(context.Background(), fmt.Sprintf("SELECT %s FROM chatlog WHERE %s AND sent_at >= $%d ORDER BY sent_at LIMIT 1", chatLogColumns, filter, len(args)), args...)
+	return collectChatLog(rows)
+}
+
+// Builds the WHERE clause and arguments of a random line query.
+func randomLineFilter(chatid int, userid int) (string, []any) {
+	args := []any{chatid}
+	filter := "chatid = $1 AND kind = 'PRIVMSG' AND " + withoutOptOuts
+	if userid != 0 {
+		args = append(args, userid)
+		filter += fmt.Sprintf(" AND userid = $%d", len(args))
+	}
+	return filter, args
+}
+
+// Returns the point in time at fraction r, between 0 and 1, of the way from first to last.
+func randomTime(first time.Time, last time.Time, r float64) time.Time {
+	return first.Add(time.Duration(r * float64(last.Sub(first))))
+}
+
+// Builds the WHERE clause and arguments of a chat log search.
+func searchFilter(search models.ChatLogSearch) (string, []any) {
+	args := []any{search.ChatID}
+	filter := "chatid = $1 AND " + withoutOptOuts
+	if search.UserID != 0 {
+		args = append(args, search.UserID)
+		filter += fmt.Sprintf(" AND userid = $%d", len(args))
+	}
+	if !search.From.IsZero() {
+		args = append(args, search.From)
+		filter += fmt.Sprintf(" AND sent_at >= $%d", len(args))
+	}
+	if !search.To.IsZero() {
+		args = append(args, search.To)
+		filter += fmt.Sprintf(" AND sent_at < $%d", len(args))
+	}
+	if search.Query != "" {
+		args = append(args, search.Query)
+		filter += fmt.Sprintf(" AND search @@ websearch_to_tsquery('simple', $%d)", len(args))
+	}
+	return filter, args
+}
+
+// Search the chat logs of a chat, newest first.
+func SearchChatLogs(db *pgxpool.Pool, search models.ChatLogSearch, limit int, offset int) ([]models.ChatLogEntry, error) {
+	filter, args := searchFilter(search)
+	args = append(args, limit, offset)
+	rows, _ := db.Query(context.Background(), fmt.Sprintf("SELECT "+chatLogColumns+" FROM chatlog WHERE %s ORDER BY sent_at DESC LIMIT $%d OFFSET $%d", filter, len(args)-1, len(args)), args...)
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ChatLogEntry])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return entries, nil
+}
+
+// Count the results of a chat log search.
+func CountChatLogSearch(db *pgxpool.Pool, search models.ChatLogSearch) (int, error) {
+	filter, args := searchFilter(search)
+	var count int
+	err := db.QueryRow(context.Background(), "SELECT count(*) FROM chatlog WHERE "+filter, args...).Scan(&count)
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return count, nil
+}
+
+// Get up to n chat logs of a chat sent before at, and up to n sent at or after it, oldest first.
+func GetChatLogsAround(db *pgxpool.Pool, chatid int, at time.Time, n int) ([]models.ChatLogEntry, error) {
+	rows, _ := db.Query(context.Background(), `
+(SELECT `+chatLogColumns+` FROM chatlog WHERE chatid = $1 AND sent_at < $2 AND `+withoutOptOuts+` ORDER BY sent_at DESC LIMIT $3)
+UNION ALL
+(SELECT `+chatLogColumns+` FROM chatlog WHERE chatid = $1 AND sent_at >= $2 AND `+withoutOptOuts+` ORDER BY sent_at LIMIT $3)
+ORDER BY sent_at`, chatid, at, n)
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ChatLogEntry])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return entries, nil
+}
+
+func collectChatLog(rows pgx.Rows) (models.ChatLogEntry, bool, error) {
+	entry, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.ChatLogEntry])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ChatLogEntry{}, false, nil
+		}
+		return models.ChatLogEntry{}, false, models.NewDatabaseError(err)
+	}
+	return entry, true, nil
+}
+
+// Drops the day partitions of a chat for days that ended before the cutoff.
+// Returns the number of dropped partitions.
+func DropChatLogsBefore(db *pgxpool.Pool, chatid int, cutoff time.Time) (int, error) {
+	rows, _ := db.Query(context.Background(), `
+SELECT child.relname FROM pg_inherits
+JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+WHERE parent.relname = $1`, chatLogPartition(chatid))
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	dropped := 0
+	for _, partition := range partitions {
+		day, err := time.Parse(partitionDayLayout, strings.TrimPrefix(partition, chatLogPartition(chatid)+"_"))
+		if err != nil {
+			// Not created by EnsureChatLogPartition
+			continue
+		}
+		if day.Add(24 * time.Hour).After(cutoff) {
+			continue
+		}
+		_, err = db.Exec(context.Background(), "DROP TABLE IF EXISTS "+pgx.Identifier{partition}.Sanitize())
+		if err != nil {
+			return dropped, models.NewDatabaseError(err)
+		}
+		dropped++
+	}
+	return dropped, nil
+}
+
+// Drops all chat logs of a chat.
+func DropChatLogs(db *pgxpool.Pool, chatid int) error {
+	_, err := db.Exec(context.Background(), "DROP TABLE IF EXISTS "+pgx.Identifier{chatLogPartition(chatid)}.Sanitize())
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRandomLineFilter(t *testing.T) {
+	filter, args := randomLineFilter(1, 0)
+	if strings.Contains(filter, "userid = $") || !slices.Equal(args, []any{1}) {
+		t.Errorf("Expected only a chat filter; Got %q with %v", filter, args)
+	}
+	filter, args = randomLineFilter(1, 2)
+	if !strings.HasSuffix(filter, " AND userid = $2") || !slices.Equal(args, []any{1, 2}) {
+		t.Errorf("Expected a user filter with $2; Got %q with %v", filter, args)
+	}
+	if !strings.Contains(filter, withoutOptOuts) {
+		t.Errorf("Expected opted out users to be excluded; Got %q", filter)
+	}
+}
+
+func TestRandomTime(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.Add(10 * time.Hour)
+	tests := []struct {
+		r        float64
+		expected time.Time
+	}{
+		{0, first},
+		{0.5, first.Add(5 * time.Hour)},
+		{1, last},
+	}
+	for _, test := range tests {
+		if actual := randomTime(first, last, test.r); !actual.Equal(test.expected) {
+			t.Errorf("r %f: Expected %s; Got %s", test.r, test.expected, actual)
+		}
+	}
+}
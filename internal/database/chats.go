@@ -4,13 +4,25 @@ import (
 	"bot/internal/models"
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const chatColumns = "chatid, chatname, prefix, reply_mode, log_enabled, log_retention, afk_notice"
+
+// Prefixes every column in a column list with a table alias, for queries with joins.
+func qualify(alias string, columns string) string {
+	qualified := strings.Split(columns, ", ")
+	for i, column := range qualified {
+		qualified[i] = alias + "." + column
+	}
+	return strings.Join(qualified, ", ")
+}
+
 func GetChats(db *pgxpool.Pool) ([]models.Chat, error) {
-	rows, err := db.Query(context.Background(), "SELECT chatid, chatname FROM chats")
+	rows, err := db.Query(context.Background(), "SELECT "+chatColumns+" FROM chats")
 	if err != nil {
 		return nil, models.NewDatabaseError(err)
 	}
@@ -22,9 +34,8 @@ func GetChats(db *pgxpool.Pool) ([]models.Chat, error) {
 }
 
 func GetChatByName(db *pgxpool.Pool, chatname string) (models.Chat, bool, error) {
-	var chat models.Chat
-	row := db.QueryRow(context.Background(), "SELECT chatid, chatname FROM chats WHERE chatname = $1", chatname)
-	err := row.Scan(&chat.ChatID, &chat.ChatName)
+	rows, _ := db.Query(context.Background(), "SELECT "+chatColumns+" FROM chats WHERE chatname = $1", chatname)
+	chat, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Chat])
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Chat{}, false, nil
@@ -34,12 +45,61 @@ func GetChatByName(db *pgxpool.Pool, chatname string) (models.Chat, bool, error)
 	return chat, true, nil
 }
 
+func GetChat(db *pgxpool.Pool, chatid int) (models.Chat, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+chatColumns+" FROM chats WHERE chatid = $1", chatid)
+	chat, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Chat])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Chat{}, false, nil
+		}
+		return models.Chat{}, false, models.NewDatabaseError(err)
+	}
+	return chat, true, nil
+}
+
+// Set the command prefix of a chat. An empty prefix resets it to the default.
+func SetChatPrefix(db *pgxpool.Pool, chatid int, prefix string) error {
+	_, err := db.Exec(context.Background(), "UPDATE chats SET prefix = $1 WHERE chatid = $2", prefix, chatid)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Set how the bot replies to commands in a chat, see [models.Chat.ReplyMode].
+func SetChatReplyMode(db *pgxpool.Pool, chatid int, mode string) error {
+	_, err := db.Exec(context.Background(), "UPDATE chats SET reply_mode = $1 WHERE chatid = $2", mode, chatid)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Set whether chat messages are logged in a chat, and for how many days logs are kept.
+func SetChatLogging(db *pgxpool.Pool, chatid int, enabled bool, retention int) error {
+	_, err := db.Exec(context.Background(), "UPDATE chats SET log_enabled = $1, log_retention = $2 WHERE chatid = $3", enabled, retention, chatid)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Set whether the bot tells users who mention an AFK user that they are away.
+func SetChatAFKNotice(db *pgxpool.Pool, chatid int, enabled bool) error {
+	_, err := db.Exec(context.Background(), "UPDATE chats SET afk_notice = $1 WHERE chatid = $2", enabled, chatid)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Deletes a chat, along with its chat logs.
 func DeleteChat(db *pgxpool.Pool, chat models.Chat) error {
 	_, err := db.Exec(context.Background(), "DELETE FROM chats WHERE chatid = $1", chat.ChatID)
 	if err != nil {
 		return models.NewDatabaseError(err)
 	}
-	return nil
+	return DropChatLogs(db, chat.ChatID)
 }
 
 func InsertChat(db *pgxpool.Pool, chat models.Chat) error {
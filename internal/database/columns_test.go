@@ -0,0 +1,65 @@
+package database
+
+import (
+	"bot/internal/models"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Rows are scanned with pgx.RowToStructByName, which fails if a struct field has no matching column.
+// Every column list must therefore contain exactly the db tags of its struct.
+func TestColumnLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns string
+		model   any
+	}{
+		{"chatColumns", chatColumns, models.Chat{}},
+		{"commandColumns", commandColumns, models.Command{}},
+		{"historyColumns", historyColumns, models.CommandHistory{}},
+		{"timerColumns", timerColumns, models.Timer{}},
+		{"triggerColumns", triggerColumns, models.Trigger{}},
+		{"filterColumns", filterColumns, models.Filter{}},
+		{"chatLogColumns", chatLogColumns, models.ChatLogEntry{}},
+		{"reminderColumns", reminderColumns, models.Reminder{}},
+		{"afkColumns", afkColumns, models.AFK{}},
+	}
+	for _, test := range tests {
+		expected := dbTags(test.model)
+		actual := strings.Split(test.columns, ", ")
+		slices.Sort(expected)
+		slices.Sort(actual)
+		if !slices.Equal(expected, actual) {
+			t.Errorf("%s: Expected columns %v; Got %v", test.name, expected, actual)
+		}
+	}
+}
+
+// The subscribed chats are scanned into models.Chat as well, so the join must select every chat column.
+func TestSubscribedChatColumns(t *testing.T) {
+	for _, column := range dbTags(models.Chat{}) {
+		if !strings.Contains(subscribedChatsQuery, "c."+column) {
+			t.Errorf("Expected subscribed chats query to select c.%s", column)
+		}
+	}
+}
+
+func TestQualify(t *testing.T) {
+	expected := "c.chatid, c.chatname"
+	if actual := qualify("c", "chatid, chatname"); actual != expected {
+		t.Errorf("Expected %s; Got %s", expected, actual)
+	}
+}
+
+func dbTags(model any) []string {
+	var tags []string
+	typ := reflect.TypeOf(model)
+	for i := range typ.NumField() {
+		if tag := typ.Field(i).Tag.Get("db"); tag != "" && tag != "-" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -4,16 +4,18 @@ import (
 	"bot/internal/models"
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Get a command by name in a chat.
+const commandColumns = "chatid, name, reply, cooldown, minimum_role, aliases, enabled, created_by, created_at, updated_at, uses"
+
+// Get a command by name or alias in a chat.
 func GetCommand(db *pgxpool.Pool, chatid int, name string) (models.Command, bool, error) {
-	var cmd models.Command
-	row := db.QueryRow(context.Background(), "SELECT chatid, name, reply FROM commands WHERE chatid = $1 AND name = $2", chatid, name)
-	err := row.Scan(&cmd.ChatID, &cmd.Name, &cmd.Reply)
+	rows, _ := db.Query(context.Background(), "SELECT "+commandColumns+" FROM commands WHERE chatid = $1 AND (name = $2 OR $2 = ANY(aliases))", chatid, name)
+	cmd, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Command])
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Command{}, false, nil
@@ -23,8 +25,32 @@ func GetCommand(db *pgxpool.Pool, chatid int, name string) (models.Command, bool
 	return cmd, true, nil
 }
 
+// Get all commands in a chat, sorted by name.
+func GetCommands(db *pgxpool.Pool, chatid int) ([]models.Command, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+commandColumns+" FROM commands WHERE chatid = $1 ORDER BY name", chatid)
+	cmds, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Command])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return cmds, nil
+}
+
 func CreateCommand(db *pgxpool.Pool, cmd models.Command) error {
-	_, err := db.Exec(context.Background(), "INSERT INTO commands (chatid, name, reply) VALUES ($1, $2, $3)", cmd.ChatID, cmd.Name, cmd.Reply)
+	if cmd.Aliases == nil {
+		cmd.Aliases = []string{}
+	}
+	_, err := db.Exec(
+		context.Background(),
+		"INSERT INTO commands (chatid, name, reply, cooldown, minimum_role, aliases, enabled, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		cmd.ChatID,
+		cmd.Name,
+		cmd.Reply,
+		cmd.Cooldown,
+		cmd.MinimumRole,
+		cmd.Aliases,
+		cmd.Enabled,
+		cmd.CreatedBy,
+	)
 	if err != nil {
 		return models.NewDatabaseError(err)
 	}
@@ -39,9 +65,115 @@ func DeleteCommand(db *pgxpool.Pool, cmd models.Command) error {
 	return nil
 }
 
+// Delete multiple commands by name or alias in a chat. Returns the deleted commands.
+func DeleteCommands(db *pgxpool.Pool, chatid int, names []string) ([]models.Command, error) {
+	rows, _ := db.Query(context.Background(), "DELETE FROM commands WHERE chatid = $1 AND (name = ANY($2) OR aliases && $2) RETURNING "+commandColumns, chatid, names)
+	deleted, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Command])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return deleted, nil
+}
+
+func RenameCommand(db *pgxpool.Pool, cmd models.Command, newName string) error {
+	_, err := db.Exec(context.Background(), "UPDATE commands SET name = $1, updated_at = now() WHERE chatid = $2 AND name = $3", newName, cmd.ChatID, cmd.Name)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Rename multiple commands in a chat in one transaction. renames maps current names to new names.
+// A new name is removed from the aliases of its command. Either all commands are renamed, or none are.
+func RenameCommands(db *pgxpool.Pool, chatid int, renames map[string]string) error {
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	defer tx.Rollback(ctx)
+	for oldName, newName := range renames {
+		tag, err := tx.Exec(ctx, "UPDATE commands SET name = $1, aliases = array_remove(aliases, $1), updated_at = now() WHERE chatid = $2 AND name = $3", newName, chatid, oldName)
+		if err != nil {
+			return models.NewDatabaseError(err)
+		}
+		if tag.RowsAffected() == 0 {
+			return models.NewDatabaseError(fmt.Errorf("command %s does not exist", oldName))
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Create or overwrite a command with all of its fields, including the usage count and timestamps.
+// Used to restore a command from its history.
+func RestoreCommand(db *pgxpool.Pool, cmd models.Command) error {
+	if cmd.Aliases == nil {
+		cmd.Aliases = []string{}
+	}
+	_, err := db.Exec(
+		context.Background(),
+		`INSERT INTO commands (`+commandColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), $10)
+		ON CONFLICT (chatid, name) DO UPDATE SET
+			reply = EXCLUDED.reply,
+			cooldown = EXCLUDED.cooldown,
+			minimum_role = EXCLUDED.minimum_role,
+			aliases = EXCLUDED.aliases,
+			enabled = EXCLUDED.enabled,
+			updated_at = now()`,
+		cmd.ChatID,
+		cmd.Name,
+		cmd.Reply,
+		cmd.Cooldown,
+		cmd.MinimumRole,
+		cmd.Aliases,
+		cmd.Enabled,
+		cmd.CreatedBy,
+		cmd.CreatedAt,
+		cmd.Uses,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
 // Update the reply of a command.
 func UpdateCommand(db *pgxpool.Pool, cmd models.Command, newReply string) error {
-	_, err := db.Exec(context.Background(), "UPDATE commands SET reply = $1 WHERE chatid = $2 AND name = $3", newReply, cmd.ChatID, cmd.Name)
+	_, err := db.Exec(context.Background(), "UPDATE commands SET reply = $1, updated_at = now() WHERE chatid = $2 AND name = $3", newReply, cmd.ChatID, cmd.Name)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Update the cooldown, minimum role, aliases and enabled flag of a command.
+func UpdateCommandSettings(db *pgxpool.Pool, cmd models.Command) error {
+	if cmd.Aliases == nil {
+		cmd.Aliases = []string{}
+	}
+	_, err := db.Exec(
+		context.Background(),
+		"UPDATE commands SET cooldown = $1, minimum_role = $2, aliases = $3, enabled = $4, updated_at = now() WHERE chatid = $5 AND name = $6",
+		cmd.Cooldown,
+		cmd.MinimumRole,
+		cmd.Aliases,
+		cmd.Enabled,
+		cmd.ChatID,
+		cmd.Name,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Atomically increment the usage counter of a command.
+func IncrementCommandUses(db *pgxpool.Pool, cmd models.Command) error {
+	_, err := db.Exec(context.Background(), "UPDATE commands SET uses = uses + 1 WHERE chatid = $1 AND name = $2", cmd.ChatID, cmd.Name)
 	if err != nil {
 		return models.NewDatabaseError(err)
 	}
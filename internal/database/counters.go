@@ -0,0 +1,59 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Get the value of a counter. Counters that don't exist have a value of 0.
+func GetCounter(db *pgxpool.Pool, chatid int, name string) (int, error) {
+	var value int
+	err := db.QueryRow(context.Background(), "SELECT value FROM counters WHERE chatid = $1 AND name = $2", chatid, name).Scan(&value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, models.NewDatabaseError(err)
+	}
+	return value, nil
+}
+
+// Atomically add delta to a counter, creating it if it doesn't exist.
+// Returns the new value.
+func IncrementCounter(db *pgxpool.Pool, chatid int, name string, delta int) (int, error) {
+	var value int
+	err := db.QueryRow(context.Background(), `
+INSERT INTO counters (chatid, name, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (chatid, name) DO UPDATE
+SET value = counters.value + EXCLUDED.value
+RETURNING value`, chatid, name, delta).Scan(&value)
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return value, nil
+}
+
+func SetCounter(db *pgxpool.Pool, chatid int, name string, value int) error {
+	_, err := db.Exec(context.Background(), `
+INSERT INTO counters (chatid, name, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (chatid, name) DO UPDATE
+SET value = EXCLUDED.value`, chatid, name, value)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+func DeleteCounter(db *pgxpool.Pool, chatid int, name string) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM counters WHERE chatid = $1 AND name = $2", chatid, name)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -13,6 +13,12 @@ CREATE TABLE IF NOT EXISTS chats (
     chatid INTEGER PRIMARY KEY NOT NULL,
     chatname VARCHAR(50) NOT NULL
 );
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS prefix VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS reply_mode VARCHAR(10) NOT NULL DEFAULT 'mention';
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS log_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS log_retention INTEGER NOT NULL DEFAULT 30;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS afk_notice BOOLEAN NOT NULL DEFAULT FALSE;
 CREATE TABLE IF NOT EXISTS subscriptions (
     chatid INTEGER NOT NULL,
     subscription_username VARCHAR(50) NOT NULL,
@@ -30,9 +36,129 @@ CREATE TABLE IF NOT EXISTS subscribers (
 );
 CREATE TABLE IF NOT EXISTS commands (
     chatid INTEGER NOT NULL,
-    name VARCHAR(100) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
     reply VARCHAR(400) NOT NULL,
     CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+-- Command names used to be unique across all chats
+ALTER TABLE commands DROP CONSTRAINT IF EXISTS commands_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS commands_chatid_name_key ON commands (chatid, name);
+ALTER TABLE commands
+    ADD COLUMN IF NOT EXISTS cooldown INTEGER NOT NULL DEFAULT 1000,
+    ADD COLUMN IF NOT EXISTS minimum_role INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS aliases VARCHAR(100)[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS created_by VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS uses INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS command_overrides (
+    chatid INTEGER NOT NULL,
+    command VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    cooldown INTEGER,
+    minimum_role INTEGER,
+    PRIMARY KEY (chatid, command),
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS timers (
+    chatid INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    message VARCHAR(400) NOT NULL,
+    interval INTEGER NOT NULL,
+    live_only BOOLEAN NOT NULL DEFAULT FALSE,
+    min_messages INTEGER NOT NULL DEFAULT 0,
+    last_posted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (chatid, name),
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS triggers (
+    id SERIAL PRIMARY KEY,
+    chatid INTEGER NOT NULL,
+    pattern VARCHAR(200) NOT NULL,
+    regex BOOLEAN NOT NULL DEFAULT FALSE,
+    case_insensitive BOOLEAN NOT NULL DEFAULT FALSE,
+    cooldown INTEGER NOT NULL,
+    reply VARCHAR(400) NOT NULL,
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS counters (
+    chatid INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    value BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (chatid, name),
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS command_history (
+    id SERIAL PRIMARY KEY,
+    chatid INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(50) NOT NULL,
+    old JSONB,
+    new JSONB,
+    undone BOOLEAN NOT NULL DEFAULT FALSE,
+    undone_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS command_history_chatid_name_idx ON command_history (chatid, name);
+CREATE TABLE IF NOT EXISTS filters (
+    id SERIAL PRIMARY KEY,
+    chatid INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    pattern VARCHAR(200) NOT NULL DEFAULT '',
+    threshold INTEGER NOT NULL DEFAULT 0,
+    action VARCHAR(10) NOT NULL,
+    duration INTEGER NOT NULL DEFAULT 0,
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS reminders (
+    id SERIAL PRIMARY KEY,
+    chatid INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    author_login VARCHAR(50) NOT NULL,
+    target_id INTEGER NOT NULL,
+    target_login VARCHAR(50) NOT NULL,
+    message VARCHAR(400) NOT NULL,
+    due_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_chats FOREIGN KEY (chatid) REFERENCES chats (chatid) ON DELETE CASCADE
+);
+-- Set when a delivery starts, the reminder is deleted once the message has been sent
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS reminders_author_id_idx ON reminders (author_id);
+CREATE INDEX IF NOT EXISTS reminders_target_id_idx ON reminders (target_id) WHERE due_at IS NULL;
+CREATE TABLE IF NOT EXISTS afk (
+    userid INTEGER PRIMARY KEY,
+    login VARCHAR(50) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    reason VARCHAR(400) NOT NULL DEFAULT '',
+    since TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- Partitioned by chat, and each chat partition by day, see EnsureChatLogPartition.
+-- Partitions are dropped with the chat instead of using a foreign key.
+CREATE TABLE IF NOT EXISTS chatlog (
+    chatid INTEGER NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    message_id VARCHAR(50) NOT NULL DEFAULT '',
+    userid INTEGER NOT NULL DEFAULT 0,
+    login VARCHAR(50) NOT NULL DEFAULT '',
+    display_name VARCHAR(50) NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    raw TEXT NOT NULL
+) PARTITION BY LIST (chatid);
+CREATE INDEX IF NOT EXISTS chatlog_userid_sent_at_idx ON chatlog (userid, sent_at);
+CREATE INDEX IF NOT EXISTS chatlog_chatid_sent_at_idx ON chatlog (chatid, sent_at);
+-- The simple configuration does not stem words, since chat is full of emotes and mixed languages
+ALTER TABLE chatlog ADD COLUMN IF NOT EXISTS search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', text)) STORED;
+CREATE INDEX IF NOT EXISTS chatlog_search_idx ON chatlog USING GIN (search);
+-- Users whose messages are not logged or served
+CREATE TABLE IF NOT EXISTS log_optouts (
+    userid INTEGER PRIMARY KEY,
+    login VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
     `)
 	if err != nil {
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const filterColumns = "id, chatid, kind, pattern, threshold, action, duration"
+
+// Get all filters in a chat, sorted by ID.
+func GetFilters(db *pgxpool.Pool, chatid int) ([]models.Filter, error) {
+	rows, err := db.Query(context.Background(), "SELECT "+filterColumns+" FROM filters WHERE chatid = $1 ORDER BY id", chatid)
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	filters, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Filter])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return filters, nil
+}
+
+func GetFilter(db *pgxpool.Pool, chatid int, id int) (models.Filter, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+filterColumns+" FROM filters WHERE chatid = $1 AND id = $2", chatid, id)
+	filter, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Filter])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Filter{}, false, nil
+		}
+		return models.Filter{}, false, models.NewDatabaseError(err)
+	}
+	return filter, true, nil
+}
+
+// Add a filter to the database. Returns the ID of the new filter.
+func CreateFilter(db *pgxpool.Pool, filter models.Filter) (int, error) {
+	var id int
+	err := db.QueryRow(
+		context.Background(),
+		"INSERT INTO filters (chatid, kind, pattern, threshold, action, duration) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		filter.ChatID,
+		filter.Kind,
+		filter.Pattern,
+		filter.Threshold,
+		filter.Action,
+		filter.Duration,
+	).Scan(&id)
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return id, nil
+}
+
+func DeleteFilter(db *pgxpool.Pool, filter models.Filter) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM filters WHERE chatid = $1 AND id = $2", filter.ChatID, filter.ID)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const historyColumns = "id, chatid, name, action, actor, old, new, undone, undone_by, created_at"
+
+func AddCommandHistory(db *pgxpool.Pool, entry models.CommandHistory) error {
+	_, err := db.Exec(
+		context.Background(),
+		"INSERT INTO command_history (chatid, name, action, actor, old, new) VALUES ($1, $2, $3, $4, $5, $6)",
+		entry.ChatID,
+		entry.Name,
+		entry.Action,
+		entry.Actor,
+		entry.Old,
+		entry.New,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Get the latest changes to a command, including renames from name, newest first.
+// If name is empty, changes to all commands in the chat are returned.
+func GetCommandHistory(db *pgxpool.Pool, chatid int, name string, limit int) ([]models.CommandHistory, error) {
+	rows, _ := db.Query(
+		context.Background(),
+		"SELECT "+historyColumns+" FROM command_history WHERE chatid = $1 AND ($2 = '' OR name = $2 OR old->>'Name' = $2) ORDER BY id DESC LIMIT $3",
+		chatid,
+		name,
+		limit,
+	)
+	history, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.CommandHistory])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return history, nil
+}
+
+// Get the latest change to a command that has not been undone.
+func GetLastCommandChange(db *pgxpool.Pool, chatid int, name string) (models.CommandHistory, bool, error) {
+	rows, _ := db.Query(
+		context.Background(),
+		"SELECT "+historyColumns+" FROM command_history WHERE chatid = $1 AND (name = $2 OR old->>'Name' = $2) AND NOT undone ORDER BY id DESC LIMIT 1",
+		chatid,
+		name,
+	)
+	entry, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CommandHistory])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CommandHistory{}, false, nil
+		}
+		return models.CommandHistory{}, false, models.NewDatabaseError(err)
+	}
+	return entry, true, nil
+}
+
+// Mark a change as undone by actor.
+func SetCommandHistoryUndone(db *pgxpool.Pool, id int, actor string) error {
+	_, err := db.Exec(context.Background(), "UPDATE command_history SET undone = TRUE, undone_by = $1 WHERE id = $2", actor, id)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
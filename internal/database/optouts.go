@@ -0,0 +1,36 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func GetLogOptOuts(db *pgxpool.Pool) ([]models.LogOptOut, error) {
+	rows, _ := db.Query(context.Background(), "SELECT userid, login, created_at FROM log_optouts")
+	optouts, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.LogOptOut])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return optouts, nil
+}
+
+// Opt a user out of chat logging. Updates the login if the user already opted out.
+func AddLogOptOut(db *pgxpool.Pool, userid int, login string) error {
+	_, err := db.Exec(context.Background(), "INSERT INTO log_optouts (userid, login) VALUES ($1, $2) ON CONFLICT (userid) DO UPDATE SET login = EXCLUDED.login", userid, login)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Opt a user back in to chat logging. Returns false if the user had not opted out.
+func RemoveLogOptOut(db *pgxpool.Pool, userid int) (bool, error) {
+	tag, err := db.Exec(context.Background(), "DELETE FROM log_optouts WHERE userid = $1", userid)
+	if err != nil {
+		return false, models.NewDatabaseError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
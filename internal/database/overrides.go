@@ -0,0 +1,50 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Get the override of an interactive command in a chat.
+func GetCommandOverride(db *pgxpool.Pool, chatid int, command string) (models.CommandOverride, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT chatid, command, enabled, cooldown, minimum_role FROM command_overrides WHERE chatid = $1 AND command = $2", chatid, command)
+	override, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.CommandOverride])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CommandOverride{}, false, nil
+		}
+		return models.CommandOverride{}, false, models.NewDatabaseError(err)
+	}
+	return override, true, nil
+}
+
+// Create or replace the override of an interactive command.
+func SetCommandOverride(db *pgxpool.Pool, override models.CommandOverride) error {
+	_, err := db.Exec(context.Background(), `
+INSERT INTO command_overrides (chatid, command, enabled, cooldown, minimum_role)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (chatid, command) DO UPDATE
+SET enabled = EXCLUDED.enabled, cooldown = EXCLUDED.cooldown, minimum_role = EXCLUDED.minimum_role`,
+		override.ChatID,
+		override.Command,
+		override.Enabled,
+		override.Cooldown,
+		override.MinimumRole,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+func DeleteCommandOverride(db *pgxpool.Pool, chatid int, command string) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM command_overrides WHERE chatid = $1 AND command = $2", chatid, command)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const reminderColumns = "id, chatid, author_id, author_login, target_id, target_login, message, due_at, created_at, claimed_at"
+
+// Insert a reminder. Returns the reminder with its ID set.
+func CreateReminder(db *pgxpool.Pool, r models.Reminder) (models.Reminder, error) {
+	rows, _ := db.Query(context.Background(), `
+INSERT INTO reminders (chatid, author_id, author_login, target_id, target_login, message, due_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING `+reminderColumns,
+		r.ChatID, r.AuthorID, r.AuthorLogin, r.TargetID, r.TargetLogin, r.Message, r.DueAt)
+	reminder, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Reminder])
+	if err != nil {
+		return models.Reminder{}, models.NewDatabaseError(err)
+	}
+	return reminder, nil
+}
+
+// Get all reminders with a due time.
+func GetTimedReminders(db *pgxpool.Pool) ([]models.Reminder, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+reminderColumns+" FROM reminders WHERE due_at IS NOT NULL")
+	reminders, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Reminder])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return reminders, nil
+}
+
+// Get the IDs of users with reminders waiting for their next message.
+func GetMessageReminderTargets(db *pgxpool.Pool) ([]int, error) {
+	rows, _ := db.Query(context.Background(), "SELECT DISTINCT target_id FROM reminders WHERE due_at IS NULL")
+	targets, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return targets, nil
+}
+
+// Get the pending reminders created by a user, soonest first. Reminders waiting for a message are last.
+func GetRemindersByAuthor(db *pgxpool.Pool, authorID int) ([]models.Reminder, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+reminderColumns+" FROM reminders WHERE author_id = $1 AND claimed_at IS NULL ORDER BY due_at NULLS LAST, id", authorID)
+	reminders, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Reminder])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return reminders, nil
+}
+
+// Count the pending reminders created by a user.
+func CountRemindersByAuthor(db *pgxpool.Pool, authorID int) (int, error) {
+	var count int
+	err := db.QueryRow(context.Background(), "SELECT count(*) FROM reminders WHERE author_id = $1 AND claimed_at IS NULL", authorID).Scan(&count)
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return count, nil
+}
+
+// Marks a reminder as being delivered and returns it. Returns false if it was already claimed, delivered or cancelled,
+// so racing deliveries and cancellations never both succeed. Delete it with [DeleteReminder] once it has been sent.
+func ClaimReminder(db *pgxpool.Pool, id int) (models.Reminder, bool, error) {
+	rows, _ := db.Query(context.Background(), "UPDATE reminders SET claimed_at = now() WHERE id = $1 AND claimed_at IS NULL RETURNING "+reminderColumns, id)
+	reminder, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Reminder])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Reminder{}, false, nil
+		}
+		return models.Reminder{}, false, models.NewDatabaseError(err)
+	}
+	return reminder, true, nil
+}
+
+// Marks the reminders waiting for the next message of a user as being delivered and returns them, oldest first.
+func ClaimMessageReminders(db *pgxpool.Pool, targetID int) ([]models.Reminder, error) {
+	rows, _ := db.Query(context.Background(), "WITH claimed AS (UPDATE reminders SET claimed_at = now() WHERE target_id = $1 AND due_at IS NULL AND claimed_at IS NULL RETURNING "+reminderColumns+") SELECT * FROM claimed ORDER BY id", targetID)
+	reminders, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Reminder])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return reminders, nil
+}
+
+// Cancel a reminder created by a user. Returns false if the user has no pending reminder with the ID.
+func CancelReminder(db *pgxpool.Pool, authorID int, id int) (bool, error) {
+	tag, err := db.Exec(context.Background(), "DELETE FROM reminders WHERE author_id = $1 AND id = $2 AND claimed_at IS NULL", authorID, id)
+	if err != nil {
+		return false, models.NewDatabaseError(err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Delete a reminder after it has been delivered.
+func DeleteReminder(db *pgxpool.Pool, id int) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM reminders WHERE id = $1", id)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Release the claims of reminders whose delivery was interrupted, so they are delivered again.
+// Must only be called on startup, before any reminders are delivered.
+func ReleaseReminderClaims(db *pgxpool.Pool) (int, error) {
+	tag, err := db.Exec(context.Background(), "UPDATE reminders SET claimed_at = NULL WHERE claimed_at IS NOT NULL")
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -22,14 +22,16 @@ func GetSubscriptions(db *pgxpool.Pool) ([]models.Subscription, error) {
 	return subscriptions, nil
 }
 
+var subscribedChatsQuery = `
+SELECT ` + qualify("c", chatColumns) + `
+FROM subscriptions su
+JOIN chats c ON c.chatid = su.chatid
+WHERE su.subscription_userid = $1`
+
 // Get all chats that have a subscription to streamUserID.
 func GetSubscribedChats(db *pgxpool.Pool, streamUserID int) ([]models.Chat, error) {
 	var chats []models.Chat
-	rows, err := db.Query(context.Background(), `
-SELECT c.chatname, c.chatid
-FROM subscriptions su
-JOIN chats c ON c.chatid = su.chatid
-WHERE su.subscription_userid = $1`, streamUserID)
+	rows, err := db.Query(context.Background(), subscribedChatsQuery, streamUserID)
 	if err != nil {
 		return nil, models.NewDatabaseError(err)
 	}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const timerColumns = "chatid, name, message, interval, live_only, min_messages, last_posted_at"
+
+// Get all timers in a chat, sorted by name.
+func GetTimers(db *pgxpool.Pool, chatid int) ([]models.Timer, error) {
+	rows, err := db.Query(context.Background(), "SELECT "+timerColumns+" FROM timers WHERE chatid = $1 ORDER BY name", chatid)
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	timers, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Timer])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return timers, nil
+}
+
+// Get all timers in all chats where the interval has passed since they were last posted.
+func GetDueTimers(db *pgxpool.Pool) ([]models.Timer, error) {
+	rows, err := db.Query(context.Background(), "SELECT "+timerColumns+" FROM timers WHERE last_posted_at + make_interval(mins => interval) <= now()")
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	timers, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Timer])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return timers, nil
+}
+
+func GetTimer(db *pgxpool.Pool, chatid int, name string) (models.Timer, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+timerColumns+" FROM timers WHERE chatid = $1 AND name = $2", chatid, name)
+	timer, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Timer])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Timer{}, false, nil
+		}
+		return models.Timer{}, false, models.NewDatabaseError(err)
+	}
+	return timer, true, nil
+}
+
+func CreateTimer(db *pgxpool.Pool, timer models.Timer) error {
+	_, err := db.Exec(
+		context.Background(),
+		"INSERT INTO timers (chatid, name, message, interval, live_only, min_messages) VALUES ($1, $2, $3, $4, $5, $6)",
+		timer.ChatID,
+		timer.Name,
+		timer.Message,
+		timer.Interval,
+		timer.LiveOnly,
+		timer.MinMessages,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Update the message, interval, live only flag and minimum messages of a timer.
+func UpdateTimer(db *pgxpool.Pool, timer models.Timer) error {
+	_, err := db.Exec(
+		context.Background(),
+		"UPDATE timers SET message = $1, interval = $2, live_only = $3, min_messages = $4 WHERE chatid = $5 AND name = $6",
+		timer.Message,
+		timer.Interval,
+		timer.LiveOnly,
+		timer.MinMessages,
+		timer.ChatID,
+		timer.Name,
+	)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+// Mark a timer as posted, restarting its interval.
+func SetTimerPosted(db *pgxpool.Pool, timer models.Timer) error {
+	_, err := db.Exec(context.Background(), "UPDATE timers SET last_posted_at = now() WHERE chatid = $1 AND name = $2", timer.ChatID, timer.Name)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
+
+func DeleteTimer(db *pgxpool.Pool, timer models.Timer) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM timers WHERE chatid = $1 AND name = $2", timer.ChatID, timer.Name)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
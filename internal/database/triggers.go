@@ -0,0 +1,64 @@
+package database
+
+import (
+	"bot/internal/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const triggerColumns = "id, chatid, pattern, regex, case_insensitive, cooldown, reply"
+
+// Get all triggers in a chat, sorted by ID.
+func GetTriggers(db *pgxpool.Pool, chatid int) ([]models.Trigger, error) {
+	rows, err := db.Query(context.Background(), "SELECT "+triggerColumns+" FROM triggers WHERE chatid = $1 ORDER BY id", chatid)
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	triggers, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Trigger])
+	if err != nil {
+		return nil, models.NewDatabaseError(err)
+	}
+	return triggers, nil
+}
+
+func GetTrigger(db *pgxpool.Pool, chatid int, id int) (models.Trigger, bool, error) {
+	rows, _ := db.Query(context.Background(), "SELECT "+triggerColumns+" FROM triggers WHERE chatid = $1 AND id = $2", chatid, id)
+	trigger, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Trigger])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Trigger{}, false, nil
+		}
+		return models.Trigger{}, false, models.NewDatabaseError(err)
+	}
+	return trigger, true, nil
+}
+
+// Add a trigger to the database. Returns the ID of the new trigger.
+func CreateTrigger(db *pgxpool.Pool, trigger models.Trigger) (int, error) {
+	var id int
+	err := db.QueryRow(
+		context.Background(),
+		"INSERT INTO triggers (chatid, pattern, regex, case_insensitive, cooldown, reply) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		trigger.ChatID,
+		trigger.Pattern,
+		trigger.Regex,
+		trigger.CaseInsensitive,
+		trigger.Cooldown,
+		trigger.Reply,
+	).Scan(&id)
+	if err != nil {
+		return 0, models.NewDatabaseError(err)
+	}
+	return id, nil
+}
+
+func DeleteTrigger(db *pgxpool.Pool, trigger models.Trigger) error {
+	_, err := db.Exec(context.Background(), "DELETE FROM triggers WHERE chatid = $1 AND id = $2", trigger.ChatID, trigger.ID)
+	if err != nil {
+		return models.NewDatabaseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Package filters checks chat messages against per-chat moderation filters,
+// and punishes users whose messages match.
+// Filters are compiled once per chat and cached in memory.
+package filters
+
+import (
+	"bot/internal/database"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A chat message to check.
+type Message struct {
+	UserID int
+	Login  string
+	Text   string
+	// Number of Twitch emotes in the message
+	Emotes int
+}
+
+// How long a permit allows a user to post links.
+const PermitDuration = 60 * time.Second
+
+// Identical messages are only counted as repeats within this window.
+const repeatWindow = 5 * time.Minute
+
+// Caps and symbols filters ignore messages shorter than this, like "LOL" or "?!".
+const minRatioLength = 10
+
+// Default thresholds, used when a filter is added without one.
+var DefaultThresholds = map[string]int{
+	models.FilterCaps:    70,
+	models.FilterSymbols: 50,
+	models.FilterEmotes:  10,
+	models.FilterRepeat:  2,
+	models.FilterLength:  300,
+}
+
+var linkRegex = regexp.MustCompile(`(?i)(https?://\S|www\.\S|\b[a-z0-9-]+\.(com|net|org|io|gg|tv|me|co|xyz|ly|be|to|ru|de|uk|link|app|dev|info|biz|live|shop|site)\b)`)
+
+type compiled struct {
+	filter models.Filter
+	// Set for regex filters
+	regex *regexp.Regexp
+	// Lowercased pattern for phrase filters
+	phrase string
+}
+
+type repeat struct {
+	text   string
+	count  int
+	lastAt time.Time
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[int][]compiled)
+	// Expiry of link permits, by chat ID and login
+	permits = make(map[int]map[string]time.Time)
+	// Last message of each user, by chat ID and user ID. Only tracked in chats with a repeat filter.
+	repeats = make(map[int]map[int]repeat)
+)
+
+func compile(f models.Filter) (compiled, error) {
+	c := compiled{filter: f}
+	switch f.Kind {
+	case models.FilterRegex:
+		regex, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return compiled{}, err
+		}
+		c.regex = regex
+	case models.FilterPhrase:
+		c.phrase = strings.ToLower(f.Pattern)
+	}
+	return c, nil
+}
+
+// Validate returns an error if the pattern of a filter is invalid.
+func Validate(f models.Filter) error {
+	_, err := compile(f)
+	return err
+}
+
+// Invalidate removes a chats filters from the cache. Must be called after filters are modified.
+func Invalidate(chatID int) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(cache, chatID)
+}
+
+// Permit allows a user to post links in a chat for [PermitDuration].
+func Permit(chatID int, login string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if permits[chatID] == nil {
+		permits[chatID] = make(map[string]time.Time)
+	}
+	permits[chatID][login] = time.Now().Add(PermitDuration)
+}
+
+func permitted(chatID int, login string) bool {
+	expiry, found := permits[chatID][login]
+	if found && time.Now().After(expiry) {
+		delete(permits[chatID], login)
+		return false
+	}
+	return found
+}
+
+// Returns the percentage of runes in text that match f, ignoring whitespace.
+// Returns 0 for texts shorter than minRatioLength.
+func ratio(text string, total func(rune) bool, f func(rune) bool) int {
+	matching, all := 0, 0
+	for _, r := range text {
+		if !total(r) {
+			continue
+		}
+		all++
+		if f(r) {
+			matching++
+		}
+	}
+	if all < minRatioLength {
+		return 0
+	}
+	return matching * 100 / all
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func isNotSpace(r rune) bool {
+	return !unicode.IsSpace(r)
+}
+
+// Returns true if msg should be filtered. repeats is the number of identical messages
+// the user sent before this one, and permitted is true if the user may post links.
+func (c compiled) matches(msg Message, repeats int, permitted bool) bool {
+	f := c.filter
+	switch f.Kind {
+	case models.FilterPhrase:
+		return strings.Contains(strings.ToLower(msg.Text), c.phrase)
+	case models.FilterRegex:
+		return c.regex.MatchString(msg.Text)
+	case models.FilterLinks:
+		return !permitted && linkRegex.MatchString(msg.Text)
+	case models.FilterCaps:
+		return ratio(msg.Text, unicode.IsLetter, unicode.IsUpper) > f.Threshold
+	case models.FilterSymbols:
+		return ratio(msg.Text, isNotSpace, isSymbol) > f.Threshold
+	case models.FilterEmotes:
+		return msg.Emotes > f.Threshold
+	case models.FilterRepeat:
+		return repeats >= f.Threshold
+	case models.FilterLength:
+		return utf8.RuneCountInString(msg.Text) > f.Threshold
+	}
+	return false
+}
+
+// Returns the compiled filters of a chat, loading them from the database if they are not cached.
+func load(state *models.State, chatID int) ([]compiled, error) {
+	if filters, found := cache[chatID]; found {
+		return filters, nil
+	}
+	filters, err := database.GetFilters(state.DB, chatID)
+	if err != nil {
+		return nil, err
+	}
+	compiledFilters := make([]compiled, 0, len(filters))
+	for _, f := range filters {
+		// Filters are validated before they are added, so this should never fail
+		if c, err := compile(f); err == nil {
+			compiledFilters = append(compiledFilters, c)
+		}
+	}
+	cache[chatID] = compiledFilters
+	return compiledFilters, nil
+}
+
+// Counts how many identical messages in a row the user sent before this one.
+func countRepeats(chatID int, msg Message) int {
+	if repeats[chatID] == nil {
+		repeats[chatID] = make(map[int]repeat)
+	}
+	last := repeats[chatID][msg.UserID]
+	text := strings.ToLower(strings.TrimSpace(msg.Text))
+	if last.text == text && time.Since(last.lastAt) < repeatWindow {
+		last.count++
+	} else {
+		last = repeat{text: text}
+	}
+	last.lastAt = time.Now()
+	repeats[chatID][msg.UserID] = last
+	return last.count
+}
+
+// Check returns the first filter in a chat that msg matches.
+// Callers are responsible for exempting moderators, VIPs and the broadcaster.
+func Check(state *models.State, chatID int, msg Message) (models.Filter, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	filters, err := load(state, chatID)
+	if err != nil {
+		return models.Filter{}, false, err
+	}
+	repeatCount := 0
+	for _, c := range filters {
+		if c.filter.Kind == models.FilterRepeat {
+			repeatCount = countRepeats(chatID, msg)
+			break
+		}
+	}
+	for _, c := range filters {
+		if c.matches(msg, repeatCount, permitted(chatID, msg.Login)) {
+			return c.filter, true, nil
+		}
+	}
+	return models.Filter{}, false, nil
+}
+
+// Apply takes the action of a filter against the sender of a message.
+func Apply(state *models.State, chatID int, messageID string, userID int, f models.Filter) error {
+	reason := fmt.Sprintf("Message matched %s filter #%d", f.Kind, f.ID)
+	switch f.Action {
+	case models.ActionDelete:
+		return helix.DeleteMessage(state.Http, chatID, state.BotUserID, messageID)
+	case models.ActionTimeout:
+		return helix.TimeoutUser(state.Http, chatID, state.BotUserID, userID, time.Duration(f.Duration)*time.Second, reason)
+	case models.ActionBan:
+		return helix.BanUser(state.Http, chatID, state.BotUserID, userID, reason)
+	case models.ActionWarn:
+		return helix.WarnUser(state.Http, chatID, state.BotUserID, userID, reason)
+	}
+	return fmt.Errorf("Unknown filter action %s", f.Action)
+}
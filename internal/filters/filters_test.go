@@ -0,0 +1,63 @@
+package filters
+
+import (
+	"bot/internal/models"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		filter   models.Filter
+		message  Message
+		expected bool
+	}{
+		{models.Filter{Kind: models.FilterPhrase, Pattern: "Bad Word"}, Message{Text: "this is a BAD WORD"}, true},
+		{models.Filter{Kind: models.FilterPhrase, Pattern: "bad word"}, Message{Text: "this is fine"}, false},
+		{models.Filter{Kind: models.FilterRegex, Pattern: `b[a4]d`}, Message{Text: "b4d"}, true},
+		{models.Filter{Kind: models.FilterLinks}, Message{Text: "check out example.com"}, true},
+		{models.Filter{Kind: models.FilterLinks}, Message{Text: "https://twitch.tv/linneb"}, true},
+		{models.Filter{Kind: models.FilterLinks}, Message{Text: "no links here, just a sentence.ok"}, false},
+		{models.Filter{Kind: models.FilterCaps, Threshold: 70}, Message{Text: "WHY IS EVERYONE SHOUTING"}, true},
+		{models.Filter{Kind: models.FilterCaps, Threshold: 70}, Message{Text: "LOL"}, false},
+		{models.Filter{Kind: models.FilterCaps, Threshold: 70}, Message{Text: "Normal Sentence With Capitals"}, false},
+		{models.Filter{Kind: models.FilterSymbols, Threshold: 50}, Message{Text: "!!!!!!!!!!!!?????"}, true},
+		{models.Filter{Kind: models.FilterSymbols, Threshold: 50}, Message{Text: "hello there!"}, false},
+		{models.Filter{Kind: models.FilterEmotes, Threshold: 3}, Message{Text: "Kappa Kappa Kappa Kappa", Emotes: 4}, true},
+		{models.Filter{Kind: models.FilterEmotes, Threshold: 3}, Message{Text: "Kappa Kappa Kappa", Emotes: 3}, false},
+		{models.Filter{Kind: models.FilterLength, Threshold: 10}, Message{Text: "åäöåäöåäöå"}, false},
+		{models.Filter{Kind: models.FilterLength, Threshold: 10}, Message{Text: "åäöåäöåäöåä"}, true},
+	}
+	for _, test := range tests {
+		c, err := compile(test.filter)
+		if err != nil {
+			t.Fatalf("Filter %+v: Expected no error; Got %s", test.filter, err)
+		}
+		if actual := c.matches(test.message, 0, false); actual != test.expected {
+			t.Errorf("Filter %+v, message %q: Expected %t; Got %t", test.filter, test.message.Text, test.expected, actual)
+		}
+	}
+}
+
+func TestPermit(t *testing.T) {
+	c, _ := compile(models.Filter{Kind: models.FilterLinks})
+	msg := Message{Login: "linneb", Text: "example.com"}
+	Permit(1, "linneb")
+	if c.matches(msg, 0, permitted(1, "linneb")) {
+		t.Errorf("Expected permitted user to be allowed to post links")
+	}
+	if !c.matches(msg, 0, permitted(2, "linneb")) {
+		t.Errorf("Expected permit to only apply to its chat")
+	}
+}
+
+func TestRepeats(t *testing.T) {
+	msg := Message{UserID: 1, Text: "spam"}
+	for i := range 3 {
+		if count := countRepeats(1, msg); count != i {
+			t.Errorf("Expected %d repeats; Got %d", i, count)
+		}
+	}
+	if count := countRepeats(1, Message{UserID: 1, Text: "something else"}); count != 0 {
+		t.Errorf("Expected repeats to reset; Got %d", count)
+	}
+}
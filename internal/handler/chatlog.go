@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bot/internal/chatlog"
+	"bot/internal/commands"
+	"bot/internal/models"
+	"log"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+// Logs subscriptions, raids and other user notices.
+func OnUserNotice(state *models.State) func(irc.UserNoticeMessage) {
+	return func(msg irc.UserNoticeMessage) {
+		entry, err := chatlog.FromUserNotice(msg)
+		logEntry(state, entry, err)
+	}
+}
+
+// Logs bans, timeouts and chat clears.
+func OnClearChat(state *models.State) func(irc.ClearChatMessage) {
+	return func(msg irc.ClearChatMessage) {
+		entry, err := chatlog.FromClearChat(msg)
+		logEntry(state, entry, err)
+	}
+}
+
+// Logs deleted messages.
+func OnClearMessage(state *models.State) func(irc.ClearMessage) {
+	return func(msg irc.ClearMessage) {
+		entry, err := chatlog.FromClearMessage(msg)
+		logEntry(state, entry, err)
+	}
+}
+
+// Queues entry in the chat log if logging is enabled in its chat, and the user has not opted out.
+// Takes the error of the conversion, so callers don't have to check it.
+func logEntry(state *models.State, entry models.ChatLogEntry, err error) {
+	if err != nil {
+		log.Printf("Could not convert message for chat log: %s", err)
+		return
+	}
+	chat, found, err := commands.Handler.GetChat(state, entry.ChatID)
+	if err != nil {
+		log.Printf("Could not get chat: %s", err)
+		return
+	}
+	if !found || !chat.LogEnabled {
+		return
+	}
+	optedOut, err := chatlog.OptedOut(state, entry.UserID, entry.Login)
+	if err != nil {
+		log.Printf("Could not get log opt-outs: %s", err)
+		return
+	}
+	if !optedOut {
+		chatlog.Log(entry)
+	}
+}
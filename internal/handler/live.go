@@ -4,6 +4,7 @@ import (
 	"bot/internal/database"
 	"bot/internal/helix"
 	"bot/internal/models"
+	"bot/internal/queue"
 	"bot/internal/utils"
 	"encoding/json"
 	"fmt"
@@ -68,7 +69,7 @@ func OnLive(state *models.State) func(rawEvent json.RawMessage) {
 
 		for chat, users := range subscribers {
 			for _, message := range utils.SplitStreamOnlineMessage(liveMessage, users, 450) {
-				state.IRC.Say(chat, message)
+				state.Queue.Say(chat, message, queue.PriorityLow)
 			}
 		}
 	}
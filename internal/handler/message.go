@@ -1,55 +1,132 @@
 package handler
 
 import (
+	"bot/internal/afk"
+	"bot/internal/chatlog"
 	"bot/internal/commands"
 	"bot/internal/database"
+	"bot/internal/filters"
+	"bot/internal/helix"
 	"bot/internal/models"
+	"bot/internal/queue"
+	"bot/internal/recent"
+	"bot/internal/reminders"
+	"bot/internal/timers"
+	"bot/internal/triggers"
+	"bot/internal/utils"
+	"bot/internal/variables"
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	irc "github.com/gempir/go-twitch-irc/v4"
 )
 
 func OnMessage(state *models.State) func(irc.PrivateMessage) {
 	return func(msg irc.PrivateMessage) {
-		if !strings.HasPrefix(msg.Message, state.Config.Prefix) {
+		channelID, err := strconv.Atoi(msg.RoomID)
+		if err != nil {
+			log.Printf("RoomID \"%s\" is not convertable to int: %s", msg.RoomID, err)
+			return
+		}
+		chat, _, err := commands.Handler.GetChat(state, channelID)
+		if err != nil {
+			log.Printf("Could not get chat: %s", err)
+			return
+		}
+		if chat.LogEnabled {
+			entry, err := chatlog.FromPrivateMessage(msg)
+			logEntry(state, entry, err)
+		}
+		prefix, err := commands.Handler.GetPrefix(state, channelID)
+		if err != nil {
+			log.Printf("Could not get prefix: %s", err)
+			return
+		}
+		if !msg.User.IsMod && !msg.User.IsVip && !msg.User.IsBroadcaster && filter(state, channelID, msg) {
+			return
+		}
+		timers.CountMessage(channelID)
+		remember(channelID, msg)
+		if userID, err := strconv.Atoi(msg.User.ID); err != nil {
+			log.Printf("UserID \"%s\" is not convertable to int: %s", msg.User.ID, err)
+		} else {
+			reminders.OnMessage(state, msg.Channel, userID)
+			checkAFK(state, chat, msg, userID)
+		}
+		msg.Message = commands.StripReplyMention(msg)
+		if !strings.HasPrefix(msg.Message, prefix) {
+			trigger, found, err := triggers.Match(state, channelID, msg.Message)
+			if err != nil {
+				log.Printf("Could not match triggers: %s", err)
+				return
+			}
+			if found {
+				sendReply(state, chat, msg, trigger.Reply)
+			}
 			return
 		}
 		ctx, err := commands.NewContext(state, msg)
 		if err != nil {
 			log.Printf("Could not create command context: %s", err)
+			return
 		}
 
 		// Interactive command
 		command, found := commands.Handler.GetCommandByAlias(ctx.Invocation)
 		if found {
+			override, overridden, err := database.GetCommandOverride(state.DB, ctx.ChannelID, command.Metadata.Name)
+			if err != nil {
+				log.Printf("Could not query database: %s", err)
+				return
+			}
+			if overridden {
+				if !override.Enabled {
+					return
+				}
+				command.Metadata = command.Metadata.WithOverride(override)
+			}
 			if ctx.Role < command.Metadata.MinimumRole {
 				return
 			}
 			if commands.Handler.IsOnCooldown(ctx.SenderUserID, command.Metadata.Name, command.Metadata.Cooldown) {
 				return
 			}
+			args, usageError := command.Metadata.ParseReply(ctx.Prefix, ctx.Parameters, ctx.ParentUsername)
+			if usageError != "" {
+				sendReply(state, chat, msg, usageError)
+				return
+			}
+			// Only after parsing, so users can retry right away with the correct usage
 			commands.Handler.SetCooldown(ctx.SenderUserID, command.Metadata.Name)
+			ctx.Args = args
 			now := time.Now()
 			reply, err := command.Run(state, ctx)
 			if err != nil {
 				var ae *models.APIError
 				if errors.As(err, &ae) {
 					log.Printf("%s", err)
-					state.IRC.Say(msg.Channel, fmt.Sprintf("@%s, :( 3rd party API failure.", msg.User.Name))
+					sendReply(state, chat, msg, ":( 3rd party API failure.")
 				} else {
 					log.Printf("Command execution failed: %s", err)
 				}
 				return
 			}
 			log.Printf("Executed %s in %s", command.Metadata.Name, time.Since(now))
-			if reply != "" {
-				state.IRC.Say(msg.Channel, fmt.Sprintf("@%s, %s", msg.User.Name, reply))
-			} else {
+			switch {
+			case reply == "":
 				log.Printf("Command returned empty reply")
+			case command.Metadata.Whisper == commands.WAlways:
+				sendWhisper(state, ctx.SenderUserID, reply)
+			case command.Metadata.Whisper == commands.WAllowed && utf8.RuneCountInString(reply) > utils.MaxMessageLength-len(msg.User.Name)-3:
+				sendWhisper(state, ctx.SenderUserID, reply)
+				sendReply(state, chat, msg, "The reply was too long for chat, so it was whispered to you.")
+			default:
+				sendReply(state, chat, msg, reply)
 			}
 			return
 		}
@@ -61,11 +138,136 @@ func OnMessage(state *models.State) func(irc.PrivateMessage) {
 			return
 		}
 		if found {
-			if commands.Handler.IsOnCooldown(ctx.SenderUserID, ctx.Invocation, 1*time.Second) {
+			if !cmd.Enabled || ctx.Role < cmd.MinimumRole {
 				return
 			}
-			commands.Handler.SetCooldown(ctx.SenderUserID, ctx.Invocation)
-			state.IRC.Say(msg.Channel, fmt.Sprintf("@%s, %s", msg.User.Name, cmd.Reply))
+			if commands.Handler.IsOnCooldown(ctx.SenderUserID, cmd.Name, time.Duration(cmd.Cooldown)*time.Millisecond) {
+				return
+			}
+			commands.Handler.SetCooldown(ctx.SenderUserID, cmd.Name)
+			if err := database.IncrementCommandUses(state.DB, cmd); err != nil {
+				log.Printf("Could not increment command uses: %s", err)
+			}
+			reply := variables.Expand(cmd.Reply, templateData(state, ctx))
+			sendReply(state, chat, msg, reply)
+		}
+	}
+}
+
+// Checks msg against the filters of the chat, and takes the action of the first matching filter.
+// Returns true if the message was filtered.
+func filter(state *models.State, channelID int, msg irc.PrivateMessage) bool {
+	userID, err := strconv.Atoi(msg.User.ID)
+	if err != nil {
+		log.Printf("UserID \"%s\" is not convertable to int: %s", msg.User.ID, err)
+		return false
+	}
+	emotes := 0
+	for _, emote := range msg.Emotes {
+		emotes += emote.Count
+	}
+	f, found, err := filters.Check(state, channelID, filters.Message{
+		UserID: userID,
+		Login:  msg.User.Name,
+		Text:   msg.Message,
+		Emotes: emotes,
+	})
+	if err != nil {
+		log.Printf("Could not check filters: %s", err)
+		return false
+	}
+	if !found {
+		return false
+	}
+	log.Printf("Message from %s in %s matched %s filter #%d, action: %s", msg.User.Name, msg.Channel, f.Kind, f.ID, f.Action)
+	// Moderation requests are slow, so don't block other messages
+	go func() {
+		if err := filters.Apply(state, channelID, msg.ID, userID, f); err != nil {
+			log.Printf("Could not apply filter: %s", err)
 		}
+	}()
+	return true
+}
+
+// Stores msg in the recent messages of the chat, used by the nuke command.
+// Messages from mods and the broadcaster are left out, since they can't be timed out.
+func remember(channelID int, msg irc.PrivateMessage) {
+	if msg.User.IsMod || msg.User.IsBroadcaster {
+		return
+	}
+	userID, err := strconv.Atoi(msg.User.ID)
+	if err != nil {
+		log.Printf("UserID \"%s\" is not convertable to int: %s", msg.User.ID, err)
+		return
+	}
+	recent.Add(channelID, recent.Message{
+		UserID: userID,
+		Login:  msg.User.Name,
+		Text:   msg.Message,
+		SentAt: msg.Time,
+	})
+}
+
+// Announces the return of the sender if they were AFK, and tells them if they mentioned someone who is AFK.
+func checkAFK(state *models.State, chat models.Chat, msg irc.PrivateMessage, userID int) {
+	status, returned, err := afk.Return(state, userID)
+	if err != nil {
+		log.Printf("Could not clear AFK status: %s", err)
+	}
+	if returned {
+		state.Queue.Say(msg.Channel, afk.ReturnMessage(status, time.Now()), queue.PriorityNormal)
+	}
+	if !chat.AFKNotice {
+		return
+	}
+	for _, mentioned := range afk.Mentioned(chat.ChatID, msg.Message) {
+		sendReply(state, chat, msg, afk.NoticeMessage(mentioned, time.Now()))
+	}
+}
+
+// Maximum number of extra messages a long reply is split into. Anything longer is truncated.
+const maxContinuations = 2
+
+// Sends a reply to msg using the reply mode of the chat, split into multiple messages if needed.
+func sendReply(state *models.State, chat models.Chat, msg irc.PrivateMessage, text string) {
+	if chat.ReplyMode == models.ReplyMention || chat.ReplyMode == "" {
+		text = fmt.Sprintf("@%s, %s", msg.User.Name, text)
+	}
+	for _, part := range utils.SplitMessage(text, utils.MaxMessageLength, 1+maxContinuations) {
+		if chat.ReplyMode == models.ReplyThread {
+			state.Queue.Reply(msg.Channel, msg.ID, part, queue.PriorityHigh)
+		} else {
+			state.Queue.Say(msg.Channel, part, queue.PriorityHigh)
+		}
+	}
+}
+
+// Data for variables in static command replies.
+func templateData(state *models.State, ctx commands.Context) variables.Data {
+	return variables.Data{
+		Sender:  ctx.SenderDisplayname,
+		Parent:  ctx.ParentDisplayname,
+		Channel: ctx.ChannelName,
+		Args:    ctx.Parameters,
+		Uptime: func() (string, error) {
+			stream, found, err := helix.GetStream(state.Http, ctx.ChannelName)
+			if err != nil {
+				return "", err
+			}
+			if !found {
+				return "offline", nil
+			}
+			liveDuration := time.Since(stream.StartedAt)
+			return fmt.Sprintf("%dh %dm", int(liveDuration.Hours()), int(liveDuration.Minutes())%60), nil
+		},
+		Followers: func() (int, error) {
+			return helix.GetFollowerCount(state.Http, ctx.ChannelID)
+		},
+		Counter: func(name string, delta int) (int, error) {
+			if delta == 0 {
+				return database.GetCounter(state.DB, ctx.ChannelID, name)
+			}
+			return database.IncrementCounter(state.DB, ctx.ChannelID, name, delta)
+		},
 	}
 }
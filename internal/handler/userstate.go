@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"bot/internal/models"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+// Tracks whether the bot is a moderator, VIP or broadcaster in a channel, since that changes its rate limits.
+func OnUserState(state *models.State) func(irc.UserStateMessage) {
+	return func(msg irc.UserStateMessage) {
+		badges := msg.User.Badges
+		state.Queue.SetElevated(msg.Channel, badges["moderator"] > 0 || badges["vip"] > 0 || badges["broadcaster"] > 0)
+	}
+}
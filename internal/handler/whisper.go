@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bot/internal/commands"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/utils"
+	"errors"
+	"log"
+	"time"
+
+	irc "github.com/gempir/go-twitch-irc/v4"
+)
+
+// Runs commands sent to the bot as whispers. Only commands with [commands.WAllowed] or
+// [commands.WAlways] can be used, and static commands are not available since they belong to a chat.
+func OnWhisper(state *models.State) func(irc.WhisperMessage) {
+	return func(msg irc.WhisperMessage) {
+		ctx, err := commands.NewWhisperContext(state, msg)
+		if err != nil {
+			log.Printf("Could not create whisper context: %s", err)
+			return
+		}
+		command, found := commands.Handler.GetCommandByAlias(ctx.Invocation)
+		if !found {
+			return
+		}
+		if command.Metadata.Whisper == commands.WNever {
+			sendWhisper(state, ctx.SenderUserID, "This command can only be used in chat.")
+			return
+		}
+		if ctx.Role < command.Metadata.MinimumRole {
+			return
+		}
+		if commands.Handler.IsOnCooldown(ctx.SenderUserID, command.Metadata.Name, command.Metadata.Cooldown) {
+			return
+		}
+		args, usageError := command.Metadata.Parse(ctx.Prefix, ctx.Parameters)
+		if usageError != "" {
+			sendWhisper(state, ctx.SenderUserID, usageError)
+			return
+		}
+		// Only after parsing, so users can retry right away with the correct usage
+		commands.Handler.SetCooldown(ctx.SenderUserID, command.Metadata.Name)
+		ctx.Args = args
+		now := time.Now()
+		reply, err := command.Run(state, ctx)
+		if err != nil {
+			var ae *models.APIError
+			if errors.As(err, &ae) {
+				log.Printf("%s", err)
+				sendWhisper(state, ctx.SenderUserID, ":( 3rd party API failure.")
+			} else {
+				log.Printf("Command execution failed: %s", err)
+			}
+			return
+		}
+		log.Printf("Executed %s from whisper in %s", command.Metadata.Name, time.Since(now))
+		if reply != "" {
+			sendWhisper(state, ctx.SenderUserID, reply)
+		}
+	}
+}
+
+// Whispers text to a user, split into multiple whispers if needed.
+func sendWhisper(state *models.State, userID int, text string) {
+	for _, part := range utils.SplitMessage(text, utils.MaxMessageLength, 1+maxContinuations) {
+		err := helix.SendWhisper(state.Http, state.BotUserID, userID, part)
+		if err != nil {
+			log.Printf("Could not send whisper: %s", err)
+			return
+		}
+	}
+}
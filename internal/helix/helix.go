@@ -3,7 +3,9 @@ package helix
 import (
 	"bot/internal/http"
 	"bot/internal/models"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 )
@@ -104,6 +106,65 @@ func GetStream(c http.Client, name string) (stream models.HelixStream, found boo
 	}
 }
 
+// Gets the total number of followers of a channel using the /channels/followers endpoint.
+func GetFollowerCount(c http.Client, id int) (int, error) {
+	req := http.Request{
+		Method: "GET",
+		URL:    HelixURL + fmt.Sprintf("/channels/followers?broadcaster_id=%d", id),
+	}
+	res, err := c.GenericRequest(req)
+	if err != nil {
+		return 0, &models.APIError{
+			URL: req.Url(),
+			Err: err,
+		}
+	}
+	if res.StatusCode != 200 {
+		return 0, &models.APIError{
+			Status: res.StatusCode,
+			URL:    req.Url(),
+		}
+	}
+	var responseStruct struct {
+		Total int `json:"total"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&responseStruct)
+	if err != nil {
+		return 0, models.NewSystemError(err)
+	}
+	return responseStruct.Total, nil
+}
+
+// Sends a whisper from fromID to toID.
+// Requires the user:manage:whispers scope, and a verified phone number on the sending account.
+func SendWhisper(c http.Client, fromID int, toID int, message string) error {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return models.NewSystemError(err)
+	}
+	req := http.Request{
+		Method:  "POST",
+		URL:     HelixURL + fmt.Sprintf("/whispers?from_user_id=%d&to_user_id=%d", fromID, toID),
+		Body:    bytes.NewReader(body),
+		Headers: map[string]string{"Content-Type": "application/json"},
+	}
+	res, err := c.GenericRequest(req)
+	if err != nil {
+		return &models.APIError{
+			URL: req.Url(),
+			Err: err,
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 204 {
+		return &models.APIError{
+			Status: res.StatusCode,
+			URL:    req.Url(),
+		}
+	}
+	return nil
+}
+
 func ValidateToken(c http.Client) (bool, time.Duration, error) {
 	res, err := c.GenericRequest(http.Request{
 		Method: "GET",
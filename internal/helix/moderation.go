@@ -0,0 +1,205 @@
+package helix
+
+import (
+	"bot/internal/http"
+	"bot/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Causes of a [ModerationError]. Use [errors.Is] to check for them.
+var (
+	ErrMissingScope = errors.New("token is missing a required scope")
+	ErrUnauthorized = errors.New("token is invalid")
+	// The bot is not a moderator in the chat
+	ErrNotModerator = errors.New("not a moderator")
+	// The target is a moderator or the broadcaster, and can not be banned
+	ErrTargetIsModerator = errors.New("target is a moderator")
+	ErrAlreadyBanned     = errors.New("user is already banned")
+	ErrNotBanned         = errors.New("user is not banned")
+	// The message to delete does not exist, or is older than 6 hours
+	ErrNotFound = errors.New("not found")
+	// Another moderator is modifying the same user
+	ErrConflict    = errors.New("conflicting request")
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Error returned by moderation requests. Unwraps to both the cause and the [models.APIError],
+// so generic API error handling still works.
+type ModerationError struct {
+	// One of the Err* causes, or nil if unknown
+	Cause error
+	// Error message returned by Twitch
+	Message string
+	API     *models.APIError
+}
+
+func (me *ModerationError) Error() string {
+	return fmt.Sprintf("%s: %s", me.API, me.Message)
+}
+
+func (me *ModerationError) Unwrap() []error {
+	if me.Cause == nil {
+		return []error{me.API}
+	}
+	return []error{me.Cause, me.API}
+}
+
+// Maps a Twitch error response to a cause.
+func moderationCause(status int, message string) error {
+	lower := strings.ToLower(message)
+	switch status {
+	case 400:
+		switch {
+		case strings.Contains(lower, "already banned"):
+			return ErrAlreadyBanned
+		case strings.Contains(lower, "is not banned"):
+			return ErrNotBanned
+		case strings.Contains(lower, "may not be banned"), strings.Contains(lower, "may not be warned"), strings.Contains(lower, "moderator"):
+			return ErrTargetIsModerator
+		}
+	case 401:
+		if strings.Contains(lower, "scope") {
+			return ErrMissingScope
+		}
+		return ErrUnauthorized
+	case 403:
+		return ErrNotModerator
+	case 404:
+		return ErrNotFound
+	case 409:
+		return ErrConflict
+	case 429:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// Sends a moderation request with an optional JSON body, and decodes the response into result if it is not nil.
+// Returns a [ModerationError] if the status is not expected.
+func moderationRequest(c http.Client, method string, url string, body any, expected int, result any) error {
+	req := http.Request{
+		Method: method,
+		URL:    url,
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return models.NewSystemError(err)
+		}
+		req.Body = bytes.NewReader(data)
+		req.Headers = map[string]string{"Content-Type": "application/json"}
+	}
+	res, err := c.GenericRequest(req)
+	if err != nil {
+		return &models.APIError{
+			URL: req.Url(),
+			Err: err,
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != expected {
+		var errorBody struct {
+			Message string `json:"message"`
+		}
+		// The body is only used for the error message, so decoding errors are ignored
+		_ = json.NewDecoder(res.Body).Decode(&errorBody)
+		return &ModerationError{
+			Cause:   moderationCause(res.StatusCode, errorBody.Message),
+			Message: errorBody.Message,
+			API: &models.APIError{
+				Status: res.StatusCode,
+				URL:    req.Url(),
+			},
+		}
+	}
+	if result != nil {
+		if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+			return models.NewSystemError(err)
+		}
+	}
+	return nil
+}
+
+// Deletes a chat message. Requires the moderator:manage:chat_messages scope.
+func DeleteMessage(c http.Client, broadcasterID int, moderatorID int, messageID string) error {
+	url := HelixURL + fmt.Sprintf("/moderation/chat?broadcaster_id=%d&moderator_id=%d&message_id=%s", broadcasterID, moderatorID, url.QueryEscape(messageID))
+	return moderationRequest(c, "DELETE", url, nil, 204, nil)
+}
+
+func ban(c http.Client, broadcasterID int, moderatorID int, userID int, duration time.Duration, reason string) error {
+	data := map[string]any{
+		"user_id": fmt.Sprint(userID),
+		"reason":  reason,
+	}
+	if duration > 0 {
+		data["duration"] = int(duration.Seconds())
+	}
+	url := HelixURL + fmt.Sprintf("/moderation/bans?broadcaster_id=%d&moderator_id=%d", broadcasterID, moderatorID)
+	return moderationRequest(c, "POST", url, map[string]any{"data": data}, 200, nil)
+}
+
+// Permanently bans a user. Requires the moderator:manage:banned_users scope.
+func BanUser(c http.Client, broadcasterID int, moderatorID int, userID int, reason string) error {
+	return ban(c, broadcasterID, moderatorID, userID, 0, reason)
+}
+
+// Times out a user. The duration is rounded down to whole seconds, and must be between 1 second and 2 weeks.
+// Requires the moderator:manage:banned_users scope.
+func TimeoutUser(c http.Client, broadcasterID int, moderatorID int, userID int, duration time.Duration, reason string) error {
+	if duration < time.Second {
+		// A duration of 0 would be a permanent ban
+		duration = time.Second
+	}
+	return ban(c, broadcasterID, moderatorID, userID, duration, reason)
+}
+
+// Removes a ban or timeout. Requires the moderator:manage:banned_users scope.
+func UnbanUser(c http.Client, broadcasterID int, moderatorID int, userID int) error {
+	url := HelixURL + fmt.Sprintf("/moderation/bans?broadcaster_id=%d&moderator_id=%d&user_id=%d", broadcasterID, moderatorID, userID)
+	return moderationRequest(c, "DELETE", url, nil, 204, nil)
+}
+
+// Sends a warning that the user has to acknowledge before chatting again.
+// Requires the moderator:manage:warnings scope.
+func WarnUser(c http.Client, broadcasterID int, moderatorID int, userID int, reason string) error {
+	data := map[string]any{
+		"user_id": fmt.Sprint(userID),
+		"reason":  reason,
+	}
+	url := HelixURL + fmt.Sprintf("/moderation/warnings?broadcaster_id=%d&moderator_id=%d", broadcasterID, moderatorID)
+	return moderationRequest(c, "POST", url, map[string]any{"data": data}, 200, nil)
+}
+
+// Gets up to limit banned and timed out users in a chat, newest first, following pagination.
+// Requires the moderator:read:banned_users scope.
+func GetBannedUsers(c http.Client, broadcasterID int, limit int) ([]models.HelixBan, error) {
+	var bans []models.HelixBan
+	cursor := ""
+	for len(bans) < limit {
+		url := HelixURL + fmt.Sprintf("/moderation/banned?broadcaster_id=%d&first=%d", broadcasterID, min(limit-len(bans), 100))
+		if cursor != "" {
+			url += "&after=" + cursor
+		}
+		var page struct {
+			Data       []models.HelixBan
+			Pagination struct {
+				Cursor string `json:"cursor"`
+			} `json:"pagination"`
+		}
+		if err := moderationRequest(c, "GET", url, nil, 200, &page); err != nil {
+			return bans, err
+		}
+		bans = append(bans, page.Data...)
+		cursor = page.Pagination.Cursor
+		if cursor == "" || len(page.Data) == 0 {
+			break
+		}
+	}
+	return bans, nil
+}
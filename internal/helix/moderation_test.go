@@ -0,0 +1,44 @@
+package helix
+
+import (
+	"bot/internal/models"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestModerationCause(t *testing.T) {
+	tests := []struct {
+		status   int
+		message  string
+		expected error
+	}{
+		{400, "The user specified in the user_id field is already banned.", ErrAlreadyBanned},
+		{400, "The user specified in the user_id field is not banned.", ErrNotBanned},
+		{400, "The user specified in the user_id field may not be banned.", ErrTargetIsModerator},
+		{401, "Missing scope: moderator:manage:banned_users", ErrMissingScope},
+		{401, "Invalid OAuth token", ErrUnauthorized},
+		{403, "The user in moderator_id is not one of the broadcaster's moderators.", ErrNotModerator},
+		{429, "", ErrRateLimited},
+		{400, "Something new", nil},
+	}
+	for _, test := range tests {
+		if actual := moderationCause(test.status, test.message); actual != test.expected {
+			t.Errorf("Status %d, message %q: Expected %v; Got %v", test.status, test.message, test.expected, actual)
+		}
+	}
+}
+
+func TestModerationErrorUnwrap(t *testing.T) {
+	var err error = &ModerationError{
+		Cause: ErrNotModerator,
+		API:   &models.APIError{Status: 403, URL: &url.URL{Host: "api.twitch.tv"}},
+	}
+	if !errors.Is(err, ErrNotModerator) {
+		t.Errorf("Expected error to be ErrNotModerator")
+	}
+	var ae *models.APIError
+	if !errors.As(err, &ae) || ae.Status != 403 {
+		t.Errorf("Expected error to unwrap to APIError with status 403")
+	}
+}
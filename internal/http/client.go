@@ -1,6 +1,7 @@
 package http
 
 import (
+	"io"
 	"net/http"
 	"net/url"
 )
@@ -16,6 +17,10 @@ type Client struct {
 type Request struct {
 	Method string
 	URL    string
+	// Optional request body
+	Body io.Reader
+	// Headers set after the default headers
+	Headers map[string]string
 }
 
 // Return the URL as a parsed [*url.URL]. Panics if the URL is invalid.
@@ -32,7 +37,7 @@ func (c *Client) GenericRequest(r Request) (res *http.Response, err error) {
 	if err != nil {
 		return nil, err
 	}
-	request, err := http.NewRequest(r.Method, url.String(), nil)
+	request, err := http.NewRequest(r.Method, url.String(), r.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -45,5 +50,8 @@ func (c *Client) GenericRequest(r Request) (res *http.Response, err error) {
 			request.Header.Set(key, value)
 		}
 	}
+	for key, value := range r.Headers {
+		request.Header.Set(key, value)
+	}
 	return c.Client.Do(request)
 }
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Identifies exports made by this bot.
+const ExportFormat = "linnebot-commands-v1"
+
+// A static command in an export made by this bot.
+type ExportedCommand struct {
+	Name  string `json:"name"`
+	Reply string `json:"reply"`
+	// Cooldown in milliseconds
+	Cooldown int `json:"cooldown"`
+	// "everyone", "mod" or "broadcaster"
+	Role    string   `json:"role"`
+	Aliases []string `json:"aliases"`
+	Enabled bool     `json:"enabled"`
+}
+
+// JSON export of all static commands in a chat.
+type Export struct {
+	Format   string            `json:"format"`
+	Chat     string            `json:"chat"`
+	Commands []ExportedCommand `json:"commands"`
+}
+
+// Returns true if data looks like an export made by this bot.
+func isExport(data []byte) bool {
+	var header struct {
+		Format string `json:"format"`
+	}
+	return bytes.HasPrefix(data, []byte("{")) && json.Unmarshal(data, &header) == nil && header.Format == ExportFormat
+}
+
+// Replies in exports already use this bots variable syntax, so they are only validated.
+func parseExport(data []byte) (Result, error) {
+	var export Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		return Result{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	var result Result
+	for _, c := range export.Commands {
+		role := c.Role
+		if role != "mod" && role != "broadcaster" {
+			role = "everyone"
+		}
+		entry := Entry{
+			Name:     c.Name,
+			Cooldown: time.Duration(c.Cooldown) * time.Millisecond,
+			Role:     role,
+			Aliases:  c.Aliases,
+			Enabled:  c.Enabled,
+		}
+		result.add(entry, c.Reply, syntaxNative)
+	}
+	return result, nil
+}
@@ -0,0 +1,388 @@
+// Package importer parses command exports from other bots.
+//
+// Supported formats are JSON exports from Nightbot, StreamElements and Fossabot,
+// CSV files with a header row, and exports made by this bot (see [Export]). Known variables are translated into the syntax
+// used by [variables], and entries that can not be translated are skipped.
+package importer
+
+import (
+	"bot/internal/variables"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A command parsed from an export.
+type Entry struct {
+	Name     string
+	Reply    string
+	Cooldown time.Duration
+	// "everyone", "mod" or "broadcaster"
+	Role    string
+	Aliases []string
+	Enabled bool
+}
+
+// An entry that could not be imported.
+type Skipped struct {
+	Name   string
+	Reason string
+}
+
+type Result struct {
+	Entries []Entry
+	Skipped []Skipped
+}
+
+// Variable syntax of the bot that made the export.
+const (
+	// $(variable), used by Nightbot and Fossabot
+	syntaxParens = iota
+	// ${variable}, used by StreamElements
+	syntaxBraces
+	// Exports made by this bot, no translation needed
+	syntaxNative
+)
+
+// JSON fields used by the supported bots. Fields that aren't present are left empty.
+type record struct {
+	// Nightbot, Fossabot
+	Name string `json:"name"`
+	// StreamElements
+	Command string `json:"command"`
+	// Nightbot
+	Message string `json:"message"`
+	// StreamElements
+	Reply string `json:"reply"`
+	// Fossabot
+	Response string `json:"response"`
+	// Nightbot, seconds
+	CoolDown int `json:"coolDown"`
+	// StreamElements uses {"user": 15, "global": 5}, Fossabot uses a number of seconds
+	Cooldown json.RawMessage `json:"cooldown"`
+	// Nightbot: "everyone", "subscriber", "regular", "moderator", "owner"
+	UserLevel string `json:"userLevel"`
+	// StreamElements: 100 everyone, 250 subscriber, 400 VIP, 500 moderator, 1500 broadcaster
+	AccessLevel int `json:"accessLevel"`
+	// Fossabot
+	Role    string   `json:"role"`
+	Aliases []string `json:"aliases"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// Parse parses an export. The format is detected from the contents.
+func Parse(data []byte) (Result, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return Result{}, fmt.Errorf("file is empty")
+	}
+	if isExport(data) {
+		return parseExport(data)
+	}
+	if data[0] == '[' || data[0] == '{' {
+		return parseJSON(data)
+	}
+	return parseCSV(data)
+}
+
+func parseJSON(data []byte) (Result, error) {
+	var records []record
+	if data[0] == '{' {
+		// Nightbot API format: {"commands": [...]}
+		var wrapper struct {
+			Commands []record `json:"commands"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return Result{}, fmt.Errorf("invalid JSON: %w", err)
+		}
+		records = wrapper.Commands
+	} else if err := json.Unmarshal(data, &records); err != nil {
+		return Result{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var result Result
+	for _, r := range records {
+		entry := Entry{
+			Name:    firstNonEmpty(r.Name, r.Command),
+			Aliases: r.Aliases,
+			Enabled: r.Enabled == nil || *r.Enabled,
+			Role:    "everyone",
+		}
+		syntax := syntaxParens
+		reply := firstNonEmpty(r.Message, r.Response)
+		if r.Reply != "" {
+			// Only StreamElements uses "reply"
+			syntax = syntaxBraces
+			reply = r.Reply
+		}
+
+		entry.Cooldown = time.Duration(r.CoolDown) * time.Second
+		if len(r.Cooldown) > 0 {
+			var seconds int
+			var perUser struct {
+				User int `json:"user"`
+			}
+			if json.Unmarshal(r.Cooldown, &seconds) == nil {
+				entry.Cooldown = time.Duration(seconds) * time.Second
+			} else if json.Unmarshal(r.Cooldown, &perUser) == nil {
+				entry.Cooldown = time.Duration(perUser.User) * time.Second
+			}
+		}
+
+		switch {
+		case r.UserLevel != "":
+			entry.Role = nightbotRole(r.UserLevel)
+		case r.AccessLevel != 0:
+			entry.Role = streamElementsRole(r.AccessLevel)
+		case r.Role != "":
+			entry.Role = nightbotRole(r.Role)
+		}
+
+		result.add(entry, reply, syntax)
+	}
+	return result, nil
+}
+
+// Parses a CSV file with a header row. Recognized columns are
+// name/command, message/reply/response, cooldown (seconds) and userlevel/role.
+// Variables are assumed to use the $(variable) syntax.
+func parseCSV(data []byte) (Result, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) < 2 {
+		return Result{}, fmt.Errorf("CSV file must have a header row and at least one command")
+	}
+	columns := make(map[string]int)
+	for i, column := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	get := func(row []string, names ...string) string {
+		for _, name := range names {
+			if i, found := columns[name]; found && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+	if get(rows[0], "name", "command") == "" || get(rows[0], "message", "reply", "response") == "" {
+		return Result{}, fmt.Errorf("CSV header must have a name and a message column")
+	}
+
+	var result Result
+	for _, row := range rows[1:] {
+		entry := Entry{
+			Name:    get(row, "name", "command"),
+			Role:    nightbotRole(get(row, "userlevel", "role")),
+			Enabled: true,
+		}
+		if seconds, err := strconv.Atoi(get(row, "cooldown")); err == nil {
+			entry.Cooldown = time.Duration(seconds) * time.Second
+		}
+		result.add(entry, get(row, "message", "reply", "response"), syntaxParens)
+	}
+	return result, nil
+}
+
+// Normalizes and translates an entry, adding it to Entries or Skipped.
+func (r *Result) add(entry Entry, reply string, syntax int) {
+	entry.Name = normalizeName(entry.Name)
+	if entry.Name == "" {
+		r.Skipped = append(r.Skipped, Skipped{Name: "(unnamed)", Reason: "missing name"})
+		return
+	}
+	for i, alias := range entry.Aliases {
+		entry.Aliases[i] = normalizeName(alias)
+	}
+	if reply == "" {
+		r.Skipped = append(r.Skipped, Skipped{Name: entry.Name, Reason: "empty reply"})
+		return
+	}
+	translated := reply
+	if syntax != syntaxNative {
+		var err error
+		translated, err = translate(reply, entry.Name, syntax)
+		if err != nil {
+			r.Skipped = append(r.Skipped, Skipped{Name: entry.Name, Reason: err.Error()})
+			return
+		}
+	}
+	if _, err := variables.Parse(translated); err != nil {
+		r.Skipped = append(r.Skipped, Skipped{Name: entry.Name, Reason: err.Error()})
+		return
+	}
+	entry.Reply = translated
+	r.Entries = append(r.Entries, entry)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), "!"))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func nightbotRole(level string) string {
+	switch strings.ToLower(level) {
+	case "owner", "broadcaster":
+		return "broadcaster"
+	case "moderator", "mod":
+		return "mod"
+	}
+	// Subscriber, regular and VIP levels don't exist in this bot
+	return "everyone"
+}
+
+func streamElementsRole(level int) string {
+	switch {
+	case level >= 1500:
+		return "broadcaster"
+	case level >= 500:
+		return "mod"
+	}
+	return "everyone"
+}
+
+var (
+	parensRegex = regexp.MustCompile(`\$\(([^()]*)\)`)
+	bracesRegex = regexp.MustCompile(`\$\{([^{}]*)\}`)
+	numberRegex = regexp.MustCompile(`^[1-9]$`)
+	rangeRegex  = regexp.MustCompile(`^(-?\d+)\s*-\s*(-?\d+)$`)
+)
+
+// Translates the variables in a reply. Returns an error with the first unsupported variable.
+// name is the name of the command, used for counter variables.
+func translate(reply string, name string, syntax int) (string, error) {
+	regex := parensRegex
+	if syntax == syntaxBraces {
+		regex = bracesRegex
+	} else if strings.Contains(reply, "${") {
+		// Literal ${ would be parsed as a variable by this bot
+		return "", fmt.Errorf("reply contains ${")
+	}
+	// The regexes only match variables without nested variables, like $(query) in $(urlfetch $(query))
+	if nested := nestedVariable(reply, regex, syntax); nested != "" {
+		return "", fmt.Errorf("unsupported variable %s", nested)
+	}
+	var unsupported string
+	translated := regex.ReplaceAllStringFunc(reply, func(match string) string {
+		inner := strings.TrimSpace(regex.FindStringSubmatch(match)[1])
+		variable, found := translateVariable(inner, name, syntax)
+		if !found && unsupported == "" {
+			unsupported = match
+		}
+		return variable
+	})
+	if unsupported != "" {
+		return "", fmt.Errorf("unsupported variable %s", unsupported)
+	}
+	return translated, nil
+}
+
+// Returns the first variable in reply that contains other variables, or "" if there is none.
+func nestedVariable(reply string, regex *regexp.Regexp, syntax int) string {
+	open, close := "$(", byte(')')
+	if syntax == syntaxBraces {
+		open, close = "${", '}'
+	}
+	starts := make(map[int]bool)
+	for _, match := range regex.FindAllStringIndex(reply, -1) {
+		starts[match[0]] = true
+	}
+	for i := 0; i < len(reply); i++ {
+		if !strings.HasPrefix(reply[i:], open) || starts[i] {
+			continue
+		}
+		depth := 0
+		for j := i + 1; j < len(reply); j++ {
+			switch reply[j] {
+			case open[1]:
+				depth++
+			case close:
+				depth--
+			}
+			if depth == 0 {
+				return reply[i : j+1]
+			}
+		}
+		// No closing bracket, so it is not a variable
+	}
+	return ""
+}
+
+func translateVariable(inner string, name string, syntax int) (string, bool) {
+	if numberRegex.MatchString(inner) {
+		return "${" + inner + "}", true
+	}
+	lower := strings.ToLower(inner)
+	keyword, args, _ := strings.Cut(lower, " ")
+	// StreamElements uses dots, like ${random.1-100} and ${random.pick 'a' 'b'}
+	if syntax == syntaxBraces {
+		keyword, args, _ = strings.Cut(strings.Replace(lower, ".", " ", 1), " ")
+	}
+	args = strings.TrimSpace(args)
+
+	switch keyword {
+	case "touser":
+		return "${user}", true
+	case "user", "sender":
+		return "${sender}", true
+	case "channel":
+		return "${channel}", true
+	case "query":
+		return "${args}", true
+	case "uptime":
+		return "${uptime}", true
+	case "count":
+		if args == "" {
+			return fmt.Sprintf("${increment %s}", counterName(name)), true
+		}
+	case "getcount":
+		if args != "" {
+			return fmt.Sprintf("${count %s}", counterName(args)), true
+		}
+	case "random":
+		if match := rangeRegex.FindStringSubmatch(args); match != nil {
+			return fmt.Sprintf("${random %s-%s}", match[1], match[2]), true
+		}
+		// StreamElements ${random.pick 'a' 'b' 'c'}
+		if strings.HasPrefix(args, "pick ") {
+			// Use the original options, since lower has been lowercased
+			return choice(inner[strings.Index(lower, "pick ")+len("pick "):]), true
+		}
+	}
+	return "", false
+}
+
+var quotedRegex = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+
+// Converts quoted options like 'a' 'b' "c" into ${choice a|b|c}.
+func choice(options string) string {
+	var values []string
+	for _, match := range quotedRegex.FindAllStringSubmatch(options, -1) {
+		values = append(values, firstNonEmpty(match[1], match[2]))
+	}
+	if len(values) == 0 {
+		values = strings.Fields(options)
+	}
+	return "${choice " + strings.Join(values, "|") + "}"
+}
+
+var counterRegex = regexp.MustCompile(`[^a-z0-9_]`)
+
+func counterName(name string) string {
+	name = counterRegex.ReplaceAllString(strings.ToLower(name), "_")
+	return name[:min(len(name), 50)]
+}
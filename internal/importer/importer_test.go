@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNightbot(t *testing.T) {
+	data := []byte(`{"commands": [
+		{"name": "!discord", "message": "Join the discord $(user)!", "coolDown": 30, "userLevel": "everyone"},
+		{"name": "!hug", "message": "$(user) hugs $(touser)", "coolDown": 5, "userLevel": "moderator"},
+		{"name": "!deaths", "message": "Died $(count) times", "coolDown": 5, "userLevel": "everyone"},
+		{"name": "!weather", "message": "$(urlfetch https://example.com/weather)", "coolDown": 5, "userLevel": "everyone"}
+	]}`)
+	result, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	expected := []Entry{
+		{Name: "discord", Reply: "Join the discord ${sender}!", Cooldown: 30 * time.Second, Role: "everyone", Enabled: true},
+		{Name: "hug", Reply: "${sender} hugs ${user}", Cooldown: 5 * time.Second, Role: "mod", Enabled: true},
+		{Name: "deaths", Reply: "Died ${increment deaths} times", Cooldown: 5 * time.Second, Role: "everyone", Enabled: true},
+	}
+	if len(result.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries; Got %d", len(expected), len(result.Entries))
+	}
+	for i, e := range expected {
+		actual := result.Entries[i]
+		if actual.Name != e.Name || actual.Reply != e.Reply || actual.Cooldown != e.Cooldown || actual.Role != e.Role || actual.Enabled != e.Enabled {
+			t.Errorf("Expected %+v; Got %+v", e, actual)
+		}
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Name != "weather" {
+		t.Errorf("Expected weather to be skipped; Got %+v", result.Skipped)
+	}
+}
+
+func TestParseStreamElements(t *testing.T) {
+	data := []byte(`[
+		{"command": "roll", "reply": "${user} rolled ${random.1-6}", "cooldown": {"user": 10, "global": 5}, "accessLevel": 100, "aliases": ["!dice"], "enabled": true},
+		{"command": "pick", "reply": "${random.pick 'Yes' 'No'}", "cooldown": {"user": 5, "global": 5}, "accessLevel": 500, "enabled": false}
+	]`)
+	result, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("Expected 2 entries; Got %d (skipped: %+v)", len(result.Entries), result.Skipped)
+	}
+	roll := result.Entries[0]
+	if roll.Reply != "${sender} rolled ${random 1-6}" {
+		t.Errorf("Expected translated reply; Got %s", roll.Reply)
+	}
+	if roll.Cooldown != 10*time.Second {
+		t.Errorf("Expected 10s cooldown; Got %s", roll.Cooldown)
+	}
+	if len(roll.Aliases) != 1 || roll.Aliases[0] != "dice" {
+		t.Errorf("Expected alias dice; Got %v", roll.Aliases)
+	}
+	pick := result.Entries[1]
+	if pick.Reply != "${choice Yes|No}" || pick.Role != "mod" || pick.Enabled {
+		t.Errorf("Unexpected entry %+v", pick)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	data := []byte("Name,Response,Cooldown\n!socials,Follow $(channel) on twitter,15\n!empty,,5\n")
+	result, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("Expected 1 entry; Got %d", len(result.Entries))
+	}
+	if result.Entries[0].Reply != "Follow ${channel} on twitter" || result.Entries[0].Cooldown != 15*time.Second {
+		t.Errorf("Unexpected entry %+v", result.Entries[0])
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Reason != "empty reply" {
+		t.Errorf("Expected empty reply to be skipped; Got %+v", result.Skipped)
+	}
+
+	if _, err := Parse([]byte("foo,bar\n1,2\n")); err == nil {
+		t.Errorf("Expected error for CSV without name/message columns")
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	data := []byte(`{"format": "linnebot-commands-v1", "chat": "linneb", "commands": [
+		{"name": "hug", "reply": "${sender} hugs ${user} ${choice <3|:)}", "cooldown": 5000, "role": "mod", "aliases": ["cuddle"], "enabled": false},
+		{"name": "broken", "reply": "${nope}", "cooldown": 1000, "role": "everyone", "aliases": [], "enabled": true}
+	]}`)
+	result, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("Expected 1 entry; Got %d (skipped: %+v)", len(result.Entries), result.Skipped)
+	}
+	hug := result.Entries[0]
+	if hug.Reply != "${sender} hugs ${user} ${choice <3|:)}" {
+		t.Errorf("Expected reply to be unchanged; Got %s", hug.Reply)
+	}
+	if hug.Cooldown != 5*time.Second || hug.Role != "mod" || hug.Enabled {
+		t.Errorf("Expected 5s cooldown, mod role and disabled; Got %+v", hug)
+	}
+	if len(hug.Aliases) != 1 || hug.Aliases[0] != "cuddle" {
+		t.Errorf("Expected alias cuddle; Got %v", hug.Aliases)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Name != "broken" {
+		t.Errorf("Expected broken to be skipped; Got %+v", result.Skipped)
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	for range MaxUploads {
+		if _, err := StoreUpload([]byte("{}")); err != nil {
+			t.Fatalf("Expected no error; Got %s", err)
+		}
+	}
+	if _, err := StoreUpload([]byte("{}")); err != ErrTooManyUploads {
+		t.Errorf("Expected %s; Got %v", ErrTooManyUploads, err)
+	}
+
+	// Expired uploads are pruned and free up space
+	uploadsMu.Lock()
+	for token, u := range uploads {
+		u.expiresAt = time.Now().Add(-time.Second)
+		uploads[token] = u
+	}
+	uploadsMu.Unlock()
+	token, err := StoreUpload([]byte("{}"))
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if _, found := GetUpload(token); !found {
+		t.Errorf("Expected upload %s to be found", token)
+	}
+	if uploadsSize != 2 {
+		t.Errorf("Expected uploads size 2; Got %d", uploadsSize)
+	}
+}
+
+func TestTranslateNested(t *testing.T) {
+	tests := []struct {
+		reply    string
+		syntax   int
+		expected string
+	}{
+		{"$(urlfetch $(query))", syntaxParens, "unsupported variable $(urlfetch $(query))"},
+		{"hi $(user) $(twitch $(touser))", syntaxParens, "unsupported variable $(twitch $(touser))"},
+		{"${customapi ${1}}", syntaxBraces, "unsupported variable ${customapi ${1}}"},
+	}
+	for _, test := range tests {
+		_, err := translate(test.reply, "test", test.syntax)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Reply %q: Expected %s; Got %v", test.reply, test.expected, err)
+		}
+	}
+
+	// Unclosed brackets are not variables
+	translated, err := translate("costs $(5 $(user)", "test", syntaxParens)
+	if err != nil || translated != "costs $(5 ${sender}" {
+		t.Errorf("Expected literal $(; Got %q, %v", translated, err)
+	}
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+)
+
+// How long uploaded files are kept in memory.
+const UploadTTL = time.Hour
+
+// Maximum size of an uploaded file.
+const MaxUploadSize = 1 << 20
+
+// Limits on the uploads kept in memory at once, so anonymous uploads can not use unbounded memory.
+const (
+	MaxUploads          = 100
+	MaxTotalUploadsSize = 32 * MaxUploadSize
+)
+
+// Returned by [StoreUpload] when the upload limits are reached.
+var ErrTooManyUploads = errors.New("too many uploads")
+
+type upload struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+var (
+	uploadsMu   sync.Mutex
+	uploads     = make(map[string]upload)
+	uploadsSize int
+)
+
+// StoreUpload keeps an uploaded export in memory for [UploadTTL], and returns a token to retrieve it.
+// Returns [ErrTooManyUploads] if storing it would exceed [MaxUploads] or [MaxTotalUploadsSize].
+func StoreUpload(data []byte) (string, error) {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	pruneUploads()
+	if len(uploads) >= MaxUploads || uploadsSize+len(data) > MaxTotalUploadsSize {
+		return "", ErrTooManyUploads
+	}
+	token := rand.Text()
+	uploads[token] = upload{
+		data:      data,
+		expiresAt: time.Now().Add(UploadTTL),
+	}
+	uploadsSize += len(data)
+	return token, nil
+}
+
+// GetUpload returns an uploaded export by token.
+func GetUpload(token string) ([]byte, bool) {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	pruneUploads()
+	u, found := uploads[token]
+	if !found {
+		return nil, false
+	}
+	return u.data, true
+}
+
+// Removes expired uploads. uploadsMu must be held.
+func pruneUploads() {
+	for token, u := range uploads {
+		if time.Now().After(u.expiresAt) {
+			uploadsSize -= len(u.data)
+			delete(uploads, token)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"bot/internal/http"
+	"bot/internal/queue"
 	"time"
 
 	"github.com/LinneB/twitchwh"
@@ -14,7 +15,10 @@ type State struct {
 	DB        *pgxpool.Pool
 	Http      http.Client
 	IRC       *irc.Client
+	Queue     *queue.Queue
 	StartedAt time.Time
+	// Twitch user ID of the bot account
+	BotUserID int
 	TwitchWH  *twitchwh.Client
 }
 
@@ -24,7 +28,11 @@ type Config struct {
 	DatabaseURL    string   `toml:"database_url"`
 	InitialChannel string   `toml:"initial_channel"`
 	Prefix         string   `toml:"prefix"`
-	Identity       struct {
+	PublicURL      string   `toml:"public_url"`
+	// Key used to sign links to pages only mods can access, like chat log search.
+	// A random key is generated on startup if empty, which invalidates links on every restart.
+	WebSecret string `toml:"web_secret"`
+	Identity  struct {
 		BotUsername  string `toml:"bot_username"`
 		HelixToken   string `toml:"helix_token"`
 		ClientID     string `toml:"client_id"`
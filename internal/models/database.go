@@ -1,10 +1,32 @@
 package models
 
+import "time"
+
 type Chat struct {
 	ChatID   int    `db:"chatid"`
 	ChatName string `db:"chatname"`
+	// Command prefix, empty to use the prefix from the config file
+	Prefix string `db:"prefix"`
+	// How the bot replies to commands, one of the Reply* constants
+	ReplyMode string `db:"reply_mode"`
+	// Whether chat messages are stored in the chatlog table
+	LogEnabled bool `db:"log_enabled"`
+	// Days chat logs are kept for
+	LogRetention int `db:"log_retention"`
+	// Whether users who mention an AFK user are told that the user is away
+	AFKNotice bool `db:"afk_notice"`
 }
 
+// Reply modes of a chat.
+const (
+	// Native Twitch reply to the command message
+	ReplyThread = "thread"
+	// Message starting with "@user, "
+	ReplyMention = "mention"
+	// Message without a mention
+	ReplyBare = "bare"
+)
+
 type Subscription struct {
 	ChatID               int    `db:"chatid"`
 	SubscriptionUsername string `db:"subscription_username"`
@@ -21,6 +43,194 @@ type Subscriber struct {
 
 type Command struct {
 	ChatID int    `db:"chatid"`
-	Name   string `db:"subscription_id"`
-	Reply  string `db:"subscriber_username"`
+	Name   string `db:"name"`
+	Reply  string `db:"reply"`
+	// Cooldown in milliseconds
+	Cooldown    int       `db:"cooldown"`
+	MinimumRole int       `db:"minimum_role"`
+	Aliases     []string  `db:"aliases"`
+	Enabled     bool      `db:"enabled"`
+	CreatedBy   string    `db:"created_by"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+	// Number of times the command has been used
+	Uses int `db:"uses"`
+}
+
+// Actions recorded in [CommandHistory].
+const (
+	HistoryCreate = "create"
+	HistoryEdit   = "edit"
+	HistoryUpdate = "update"
+	HistoryRename = "rename"
+	HistoryDelete = "delete"
+)
+
+// A change to a static command.
+type CommandHistory struct {
+	ID     int `db:"id"`
+	ChatID int `db:"chatid"`
+	// Name of the command after the change
+	Name   string `db:"name"`
+	Action string `db:"action"`
+	// Login of the user that made the change
+	Actor string `db:"actor"`
+	// Command before the change, nil for HistoryCreate
+	Old *Command `db:"old"`
+	// Command after the change, nil for HistoryDelete
+	New    *Command `db:"new"`
+	Undone bool     `db:"undone"`
+	// Login of the user that undid the change
+	UndoneBy  string    `db:"undone_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Per-chat override of an interactive commands metadata.
+// Nil fields fall back to the compiled in defaults.
+type CommandOverride struct {
+	ChatID  int    `db:"chatid"`
+	Command string `db:"command"`
+	Enabled bool   `db:"enabled"`
+	// Cooldown in milliseconds
+	Cooldown    *int `db:"cooldown"`
+	MinimumRole *int `db:"minimum_role"`
+}
+
+// Message posted periodically in a chat.
+type Timer struct {
+	ChatID  int    `db:"chatid"`
+	Name    string `db:"name"`
+	Message string `db:"message"`
+	// Interval in minutes
+	Interval int  `db:"interval"`
+	LiveOnly bool `db:"live_only"`
+	// Minimum number of chat messages since the last post
+	MinMessages  int       `db:"min_messages"`
+	LastPostedAt time.Time `db:"last_posted_at"`
+}
+
+// Reply sent when a chat message matches a pattern, without a command prefix.
+type Trigger struct {
+	ID     int `db:"id"`
+	ChatID int `db:"chatid"`
+	// Substring, or regular expression if Regex is set
+	Pattern         string `db:"pattern"`
+	Regex           bool   `db:"regex"`
+	CaseInsensitive bool   `db:"case_insensitive"`
+	// Cooldown in milliseconds
+	Cooldown int    `db:"cooldown"`
+	Reply    string `db:"reply"`
+}
+
+// Kinds of [Filter].
+const (
+	FilterPhrase  = "phrase"
+	FilterRegex   = "regex"
+	FilterLinks   = "links"
+	FilterCaps    = "caps"
+	FilterSymbols = "symbols"
+	FilterEmotes  = "emotes"
+	FilterRepeat  = "repeat"
+	FilterLength  = "length"
+)
+
+// Actions taken when a [Filter] matches.
+const (
+	ActionDelete  = "delete"
+	ActionTimeout = "timeout"
+	ActionBan     = "ban"
+	ActionWarn    = "warn"
+)
+
+// Moderation rule applied to chat messages.
+type Filter struct {
+	ID     int    `db:"id"`
+	ChatID int    `db:"chatid"`
+	Kind   string `db:"kind"`
+	// Phrase or regular expression, empty for other kinds
+	Pattern string `db:"pattern"`
+	// Messages above the threshold are filtered.
+	// Percent for caps and symbols, count for emotes and repeat, and characters for length.
+	Threshold int    `db:"threshold"`
+	Action    string `db:"action"`
+	// Timeout duration in seconds
+	Duration int `db:"duration"`
+}
+
+// Message delivered to a user at a set time, or the next time they chat.
+type Reminder struct {
+	ID int `db:"id"`
+	// Chat the reminder was created in, timed reminders are delivered there
+	ChatID      int    `db:"chatid"`
+	AuthorID    int    `db:"author_id"`
+	AuthorLogin string `db:"author_login"`
+	TargetID    int    `db:"target_id"`
+	TargetLogin string `db:"target_login"`
+	Message     string `db:"message"`
+	// Nil for reminders delivered the next time the target chats, in any chat
+	DueAt     *time.Time `db:"due_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	// Set while the reminder is being delivered
+	ClaimedAt *time.Time `db:"claimed_at"`
+}
+
+// Kinds of [AFK].
+const (
+	AFKAway  = "afk"
+	AFKSleep = "sleep"
+)
+
+// User marked as away until their next message.
+type AFK struct {
+	UserID int    `db:"userid"`
+	Login  string `db:"login"`
+	// One of the AFK* kinds
+	Kind   string    `db:"kind"`
+	Reason string    `db:"reason"`
+	Since  time.Time `db:"since"`
+}
+
+// IRC commands stored in the chat log.
+const (
+	LogPrivmsg    = "PRIVMSG"
+	LogUserNotice = "USERNOTICE"
+	LogClearChat  = "CLEARCHAT"
+	LogClearMsg   = "CLEARMSG"
+)
+
+// Logged IRC message.
+type ChatLogEntry struct {
+	ChatID int       `db:"chatid"`
+	SentAt time.Time `db:"sent_at"`
+	// One of the Log* constants
+	Kind string `db:"kind"`
+	// ID of the message, or of the deleted message for CLEARMSG. Empty for CLEARCHAT.
+	MessageID string `db:"message_id"`
+	// Sender of the message, or the target of CLEARCHAT and CLEARMSG.
+	// Zero for CLEARCHAT without a target, and for CLEARMSG since Twitch does not include the user ID.
+	UserID      int    `db:"userid"`
+	Login       string `db:"login"`
+	DisplayName string `db:"display_name"`
+	// Message text. Readable descriptions for USERNOTICE, CLEARCHAT and CLEARMSG, like "forsen has been timed out for 10 minutes".
+	Text string `db:"text"`
+	// Raw IRC line
+	Raw string `db:"raw"`
+}
+
+// Filters of a chat log search. Zero values are not filtered on.
+type ChatLogSearch struct {
+	ChatID int
+	UserID int
+	// Only messages sent at or after From, and before To
+	From time.Time
+	To   time.Time
+	// Words to search for, in websearch_to_tsquery syntax: "quoted phrases", or, and -excluded words
+	Query string
+}
+
+// User who opted out of chat logging.
+type LogOptOut struct {
+	UserID    int       `db:"userid"`
+	Login     string    `db:"login"`
+	CreatedAt time.Time `db:"created_at"`
 }
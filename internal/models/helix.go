@@ -40,3 +40,17 @@ type HelixStream struct {
 	ThumbnailURL string    `json:"thumbnail_url"`
 	IsMature     bool      `json:"is_mature"`
 }
+
+// Banned or timed out user returned from the /moderation/banned endpoint
+type HelixBan struct {
+	UserID    string `json:"user_id"`
+	UserLogin string `json:"user_login"`
+	UserName  string `json:"user_name"`
+	// RFC3339 timestamp, empty for permanent bans
+	ExpiresAt      string    `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	Reason         string    `json:"reason"`
+	ModeratorID    string    `json:"moderator_id"`
+	ModeratorLogin string    `json:"moderator_login"`
+	ModeratorName  string    `json:"moderator_name"`
+}
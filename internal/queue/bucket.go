@@ -0,0 +1,44 @@
+package queue
+
+import "time"
+
+// Token bucket that holds up to capacity tokens, refilled evenly over period.
+type bucket struct {
+	tokens   float64
+	capacity float64
+	// Tokens per second
+	rate float64
+	last time.Time
+}
+
+func newBucket(capacity int, period time.Duration, now time.Time) bucket {
+	return bucket{
+		tokens:   float64(capacity),
+		capacity: float64(capacity),
+		rate:     float64(capacity) / period.Seconds(),
+		last:     now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// Returns how long until a token is available, or 0 if one is available now.
+func (b *bucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	// Round up, so callers never sleep for 0 while a token is missing
+	return max(time.Duration((1-b.tokens)/b.rate*float64(time.Second)), time.Millisecond)
+}
+
+// Removes a token. Should only be called after wait returned 0.
+func (b *bucket) take(now time.Time) {
+	b.refill(now)
+	b.tokens--
+}
@@ -0,0 +1,215 @@
+// Package queue rate limits outgoing chat messages.
+//
+// Twitch allows 20 messages per 30 seconds in channels where the bot is a regular user,
+// and 100 messages per 30 seconds in channels where it is a moderator, VIP or the broadcaster.
+// Regular users can also only send one message per second in each channel.
+// Exceeding the global limits gets the bot muted for 30 minutes, so messages are queued
+// and sent when both the per-channel token bucket and the global sliding windows allow it.
+package queue
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Message priorities, lower values are sent first.
+const (
+	// Replies to commands and triggers
+	PriorityHigh = iota
+	// Timed messages
+	PriorityNormal
+	// Bulk notifications, like live pings
+	PriorityLow
+	priorities
+)
+
+// Maximum number of queued messages per priority. New messages are dropped when full.
+const maxQueued = 500
+
+// Sends messages to chat, implemented by [irc.Client].
+type Sender interface {
+	Say(channel string, text string)
+	Reply(channel string, parentMsgID string, text string)
+}
+
+type message struct {
+	channel string
+	text    string
+	// ID of the message to reply to, empty for normal messages
+	parentID string
+	// Called after the message is passed to the sender, can be nil
+	sent func()
+}
+
+type channelState struct {
+	// Moderator, VIP or broadcaster
+	elevated bool
+	bucket   bucket
+}
+
+type Queue struct {
+	sender Sender
+	mu     sync.Mutex
+	// Pending messages, one FIFO per priority
+	pending [priorities][]message
+	// Limit for all messages
+	global window
+	// Limit for messages in channels where the bot is not elevated
+	globalRegular window
+	channels      map[string]*channelState
+	wake          chan struct{}
+}
+
+func New(sender Sender) *Queue {
+	return &Queue{
+		sender:        sender,
+		global:        newWindow(100, 30*time.Second),
+		globalRegular: newWindow(20, 30*time.Second),
+		channels:      make(map[string]*channelState),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Regular users can send 1 message per second in a channel. Elevated users aren't limited
+// per channel by Twitch, but bursts are still spread out a little.
+func channelBucket(elevated bool, now time.Time) bucket {
+	if elevated {
+		return newBucket(5, time.Second, now)
+	}
+	return newBucket(1, 1100*time.Millisecond, now)
+}
+
+// Must be called with q.mu held.
+func (q *Queue) channel(name string, now time.Time) *channelState {
+	c, found := q.channels[name]
+	if !found {
+		c = &channelState{bucket: channelBucket(false, now)}
+		q.channels[name] = c
+	}
+	return c
+}
+
+// SetElevated updates whether the bot is a moderator, VIP or broadcaster in a channel.
+// Should be called for every USERSTATE message.
+func (q *Queue) SetElevated(channel string, elevated bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	c := q.channel(channel, now)
+	if c.elevated != elevated {
+		c.elevated = elevated
+		c.bucket = channelBucket(elevated, now)
+	}
+	q.notify()
+}
+
+// Say queues a message.
+func (q *Queue) Say(channel string, text string, priority int) {
+	q.push(message{channel: channel, text: text}, priority)
+}
+
+// SayThen queues a message, and calls sent after it has been passed to the sender.
+// sent is never called if the message is dropped because the queue is full.
+func (q *Queue) SayThen(channel string, text string, priority int, sent func()) {
+	q.push(message{channel: channel, text: text, sent: sent}, priority)
+}
+
+// Reply queues a message that is sent as a reply to the message with ID parentID.
+func (q *Queue) Reply(channel string, parentID string, text string, priority int) {
+	q.push(message{channel: channel, text: text, parentID: parentID}, priority)
+}
+
+func (q *Queue) push(msg message, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending[priority]) >= maxQueued {
+		log.Printf("Outbound queue full, dropping message to %s", msg.channel)
+		return
+	}
+	q.pending[priority] = append(q.pending[priority], msg)
+	q.notify()
+}
+
+// Depth returns the number of queued messages per priority.
+func (q *Queue) Depth() [priorities]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var depth [priorities]int
+	for i, pending := range q.pending {
+		depth[i] = len(pending)
+	}
+	return depth
+}
+
+// Must be called with q.mu held.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Removes and returns the next message that can be sent at now.
+// If no message can be sent, returns how long to wait before trying again, or 0 if the queue is empty.
+// Must be called with q.mu held.
+func (q *Queue) next(now time.Time) (message, bool, time.Duration) {
+	var wait time.Duration
+	for p := range q.pending {
+		// Messages in a channel are sent in order, so skip channels with an earlier blocked message
+		blocked := make(map[string]bool)
+		for i, msg := range q.pending[p] {
+			if blocked[msg.channel] {
+				continue
+			}
+			c := q.channel(msg.channel, now)
+			w := max(c.bucket.wait(now), q.global.wait(now))
+			if !c.elevated {
+				w = max(w, q.globalRegular.wait(now))
+			}
+			if w > 0 {
+				blocked[msg.channel] = true
+				if wait == 0 || w < wait {
+					wait = w
+				}
+				continue
+			}
+			c.bucket.take(now)
+			q.global.take(now)
+			if !c.elevated {
+				q.globalRegular.take(now)
+			}
+			q.pending[p] = append(q.pending[p][:i], q.pending[p][i+1:]...)
+			return msg, true, 0
+		}
+	}
+	return message{}, false, wait
+}
+
+// Run sends queued messages until the program exits.
+func (q *Queue) Run() {
+	for {
+		q.mu.Lock()
+		msg, found, wait := q.next(time.Now())
+		q.mu.Unlock()
+		if found {
+			if msg.parentID != "" {
+				q.sender.Reply(msg.channel, msg.parentID, msg.text)
+			} else {
+				q.sender.Say(msg.channel, msg.text)
+			}
+			if msg.sent != nil {
+				go msg.sent()
+			}
+			continue
+		}
+		if wait == 0 {
+			<-q.wake
+			continue
+		}
+		select {
+		case <-q.wake:
+		case <-time.After(wait):
+		}
+	}
+}
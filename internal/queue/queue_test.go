@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(2, 2*time.Second, now)
+	for range 2 {
+		if w := b.wait(now); w != 0 {
+			t.Fatalf("Expected token to be available; Got wait %s", w)
+		}
+		b.take(now)
+	}
+	if w := b.wait(now); w != time.Second {
+		t.Errorf("Expected wait 1s; Got %s", w)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if w := b.wait(now); w != 500*time.Millisecond {
+		t.Errorf("Expected wait 500ms; Got %s", w)
+	}
+	now = now.Add(10 * time.Second)
+	b.refill(now)
+	if b.tokens != 2 {
+		t.Errorf("Expected bucket to be capped at 2 tokens; Got %f", b.tokens)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	q := New(nil)
+	now := time.Now()
+	q.Say("a", "live ping", PriorityLow)
+	q.Say("b", "timer", PriorityNormal)
+	q.Say("c", "reply", PriorityHigh)
+	for _, expected := range []string{"reply", "timer", "live ping"} {
+		msg, found, _ := q.next(now)
+		if !found || msg.text != expected {
+			t.Errorf("Expected %s; Got %s (found: %t)", expected, msg.text, found)
+		}
+	}
+	if _, found, wait := q.next(now); found || wait != 0 {
+		t.Errorf("Expected empty queue; Got found %t, wait %s", found, wait)
+	}
+}
+
+func TestChannelLimit(t *testing.T) {
+	q := New(nil)
+	now := time.Now()
+	q.Say("a", "1", PriorityHigh)
+	q.Say("a", "2", PriorityHigh)
+	q.Say("b", "3", PriorityHigh)
+
+	// Second message in "a" has to wait, but "b" is not blocked by it
+	for _, expected := range []string{"1", "3"} {
+		msg, found, _ := q.next(now)
+		if !found || msg.text != expected {
+			t.Errorf("Expected %s; Got %s (found: %t)", expected, msg.text, found)
+		}
+	}
+	_, found, wait := q.next(now)
+	if found || wait <= 0 {
+		t.Fatalf("Expected to wait; Got found %t, wait %s", found, wait)
+	}
+	msg, found, _ := q.next(now.Add(wait))
+	if !found || msg.text != "2" {
+		t.Errorf("Expected 2 after waiting; Got %s (found: %t)", msg.text, found)
+	}
+}
+
+func TestGlobalLimit(t *testing.T) {
+	q := New(nil)
+	now := time.Now()
+	q.SetElevated("mod", true)
+	for i := range 30 {
+		q.Say(fmt.Sprintf("regular%d", i), "hi", PriorityLow)
+	}
+	sent := 0
+	for {
+		if _, found, _ := q.next(now); !found {
+			break
+		}
+		sent++
+	}
+	if sent != 20 {
+		t.Errorf("Expected 20 messages in regular channels; Got %d", sent)
+	}
+
+	// Elevated channels only use the higher global limit
+	q.Say("mod", "hi", PriorityHigh)
+	if _, found, _ := q.next(now); !found {
+		t.Errorf("Expected message in elevated channel to be sent")
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Now()
+	w := newWindow(2, 10*time.Second)
+	w.take(now)
+	w.take(now.Add(4 * time.Second))
+	if wait := w.wait(now.Add(5 * time.Second)); wait != 5*time.Second {
+		t.Errorf("Expected wait 5s; Got %s", wait)
+	}
+	if wait := w.wait(now.Add(10 * time.Second)); wait != 0 {
+		t.Errorf("Expected no wait after the oldest event left the window; Got %s", wait)
+	}
+}
+
+// Sends as fast as the queue allows for 2 minutes of fake time, and checks that no 30 second window
+// has more messages than Twitch allows.
+func TestGlobalLimitOverTime(t *testing.T) {
+	tests := []struct {
+		elevated bool
+		limit    int
+	}{
+		{false, 20},
+		{true, 100},
+	}
+	for _, test := range tests {
+		q := New(nil)
+		start := time.Now()
+		for i := range 1000 {
+			channel := fmt.Sprintf("channel%d", i)
+			q.SetElevated(channel, test.elevated)
+			q.Say(channel, "hi", PriorityLow)
+		}
+		var sent []time.Time
+		for now := start; now.Before(start.Add(2 * time.Minute)); now = now.Add(100 * time.Millisecond) {
+			for {
+				if _, found, _ := q.next(now); !found {
+					break
+				}
+				sent = append(sent, now)
+			}
+		}
+		if len(sent) < 3*test.limit {
+			t.Errorf("Elevated %t: Expected at least %d messages in 2 minutes; Got %d", test.elevated, 3*test.limit, len(sent))
+		}
+		for i, first := range sent {
+			inWindow := 0
+			for _, s := range sent[i:] {
+				if s.Sub(first) < 30*time.Second {
+					inWindow++
+				}
+			}
+			if inWindow > test.limit {
+				t.Fatalf("Elevated %t: Expected at most %d messages in 30 seconds; Got %d starting at %s", test.elevated, test.limit, inWindow, first.Sub(start))
+			}
+		}
+	}
+}
+
+type testSender struct {
+	said chan string
+}
+
+func (s testSender) Say(channel string, text string) { s.said <- text }
+
+func (s testSender) Reply(channel string, parentMsgID string, text string) { s.said <- text }
+
+func TestSayThen(t *testing.T) {
+	sender := testSender{said: make(chan string, 1)}
+	q := New(sender)
+	go q.Run()
+	sent := make(chan bool)
+	q.SayThen("a", "reminder", PriorityNormal, func() { sent <- true })
+	select {
+	case text := <-sender.said:
+		if text != "reminder" {
+			t.Errorf("Expected reminder; Got %s", text)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected message to be sent")
+	}
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Errorf("Expected sent to be called")
+	}
+}
@@ -0,0 +1,41 @@
+package queue
+
+import "time"
+
+// Sliding window log that allows at most limit events in any period.
+// Unlike a token bucket it never allows a full burst right after another one,
+// which is how Twitch counts the global message limits.
+type window struct {
+	limit  int
+	period time.Duration
+	// Times of the events in the current window, oldest first
+	events []time.Time
+}
+
+func newWindow(limit int, period time.Duration) window {
+	return window{limit: limit, period: period}
+}
+
+// Removes events that are no longer in the window ending at now.
+func (w *window) prune(now time.Time) {
+	i := 0
+	for i < len(w.events) && !w.events[i].Add(w.period).After(now) {
+		i++
+	}
+	w.events = w.events[i:]
+}
+
+// Returns how long until another event is allowed, or 0 if one is allowed now.
+func (w *window) wait(now time.Time) time.Duration {
+	w.prune(now)
+	if len(w.events) < w.limit {
+		return 0
+	}
+	return w.events[0].Add(w.period).Sub(now)
+}
+
+// Records an event. Should only be called after wait returned 0.
+func (w *window) take(now time.Time) {
+	w.prune(now)
+	w.events = append(w.events, now)
+}
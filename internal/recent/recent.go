@@ -0,0 +1,78 @@
+// Package recent keeps the latest chat messages of each channel in memory,
+// so moderation commands can act on messages that were already sent.
+package recent
+
+import (
+	"sync"
+	"time"
+)
+
+// Number of messages kept per channel. The oldest message is dropped when a channel is full.
+const Size = 1000
+
+// A chat message.
+type Message struct {
+	UserID int
+	Login  string
+	Text   string
+	SentAt time.Time
+}
+
+// Fixed size ring buffer of messages.
+type buffer struct {
+	messages []Message
+	// Index the next message is written to
+	next int
+}
+
+var (
+	mu       sync.Mutex
+	channels = make(map[int]*buffer)
+)
+
+// Add stores a message sent in a channel.
+func Add(channelID int, m Message) {
+	mu.Lock()
+	defer mu.Unlock()
+	b, found := channels[channelID]
+	if !found {
+		b = &buffer{messages: make([]Message, 0, Size)}
+		channels[channelID] = b
+	}
+	if len(b.messages) < Size {
+		b.messages = append(b.messages, m)
+	} else {
+		b.messages[b.next] = m
+	}
+	b.next = (b.next + 1) % Size
+}
+
+// Since returns the stored messages of a channel sent at or after since, oldest first.
+func Since(channelID int, since time.Time) []Message {
+	mu.Lock()
+	defer mu.Unlock()
+	b, found := channels[channelID]
+	if !found {
+		return nil
+	}
+	var messages []Message
+	// Once the buffer is full, the oldest message is the one that will be overwritten next
+	start := 0
+	if len(b.messages) == Size {
+		start = b.next
+	}
+	for i := range len(b.messages) {
+		m := b.messages[(start+i)%len(b.messages)]
+		if !m.SentAt.Before(since) {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// Clear removes all stored messages of a channel, for example when the bot leaves it.
+func Clear(channelID int) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(channels, channelID)
+}
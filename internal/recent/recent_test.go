@@ -0,0 +1,34 @@
+package recent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	now := time.Now()
+	Add(1, Message{Login: "old", SentAt: now.Add(-time.Hour)})
+	Add(1, Message{Login: "new", SentAt: now})
+	Add(2, Message{Login: "other", SentAt: now})
+	messages := Since(1, now.Add(-time.Minute))
+	if len(messages) != 1 || messages[0].Login != "new" {
+		t.Errorf("Expected only the new message; Got %v", messages)
+	}
+	if messages := Since(3, time.Time{}); len(messages) != 0 {
+		t.Errorf("Expected no messages for unknown channel; Got %v", messages)
+	}
+}
+
+func TestWraparound(t *testing.T) {
+	start := time.Now()
+	for i := range Size + 10 {
+		Add(4, Message{UserID: i, SentAt: start.Add(time.Duration(i) * time.Millisecond)})
+	}
+	messages := Since(4, time.Time{})
+	if len(messages) != Size {
+		t.Fatalf("Expected %d messages; Got %d", Size, len(messages))
+	}
+	if messages[0].UserID != 10 || messages[Size-1].UserID != Size+9 {
+		t.Errorf("Expected messages 10 to %d in order; Got %d to %d", Size+9, messages[0].UserID, messages[Size-1].UserID)
+	}
+}
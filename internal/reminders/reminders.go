@@ -0,0 +1,173 @@
+// Package reminders delivers reminders, either at a set time in the chat they were created in,
+// or the next time the target chats in any chat.
+//
+// A reminder is claimed in the database before it is delivered, so racing deliveries and
+// cancellations never both succeed, and it is only deleted after its message has been sent.
+// Claims are released on startup, so a reminder whose delivery was interrupted by a restart,
+// or whose message was dropped by a full queue, is delivered again after the restart.
+// A reminder can therefore be delivered twice if the bot stops right after sending it, but it is never lost.
+package reminders
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"bot/internal/queue"
+	"bot/internal/utils"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var (
+	mu sync.Mutex
+	// IDs of users with reminders waiting for their next message
+	waiting = make(map[int]bool)
+)
+
+// Load schedules the timed reminders stored in the database, and loads the users with reminders
+// waiting for their next message. Must be called once on startup.
+func Load(state *models.State) error {
+	released, err := database.ReleaseReminderClaims(state.DB)
+	if err != nil {
+		return err
+	}
+	if released > 0 {
+		log.Printf("Retrying %d interrupted reminder deliveries", released)
+	}
+	reminders, err := database.GetTimedReminders(state.DB)
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		schedule(state, r)
+	}
+	targets, err := database.GetMessageReminderTargets(state.DB)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range targets {
+		waiting[id] = true
+	}
+	log.Printf("Loaded %d timed reminder(s), and %d user(s) with reminders on their next message", len(reminders), len(targets))
+	return nil
+}
+
+// Add schedules a reminder that was just created.
+func Add(state *models.State, r models.Reminder) {
+	if r.DueAt != nil {
+		schedule(state, r)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	waiting[r.TargetID] = true
+}
+
+// Delivers a timed reminder when it is due. Reminders that were due while the bot was offline are delivered right away.
+func schedule(state *models.State, r models.Reminder) {
+	time.AfterFunc(time.Until(*r.DueAt), func() {
+		claimed, found, err := database.ClaimReminder(state.DB, r.ID)
+		if err != nil {
+			log.Printf("Could not claim reminder %d: %s", r.ID, err)
+			return
+		}
+		if !found {
+			// Cancelled
+			return
+		}
+		chat, found, err := database.GetChat(state.DB, claimed.ChatID)
+		if err != nil {
+			log.Printf("Could not get chat of reminder %d: %s", r.ID, err)
+			return
+		}
+		if !found {
+			// The bot left the chat
+			forget(state, claimed)
+			return
+		}
+		deliver(state, chat.ChatName, claimed)
+	})
+}
+
+// OnMessage delivers the reminders waiting for the next message of a user, in the chat they sent it in.
+func OnMessage(state *models.State, channel string, userID int) {
+	mu.Lock()
+	if !waiting[userID] {
+		mu.Unlock()
+		return
+	}
+	delete(waiting, userID)
+	mu.Unlock()
+
+	reminders, err := database.ClaimMessageReminders(state.DB, userID)
+	if err != nil {
+		log.Printf("Could not claim reminders: %s", err)
+		return
+	}
+	for _, r := range reminders {
+		deliver(state, channel, r)
+	}
+}
+
+func deliver(state *models.State, channel string, r models.Reminder) {
+	from := "from " + r.AuthorLogin
+	if r.AuthorID == r.TargetID {
+		from = "you set"
+	}
+	text := fmt.Sprintf("@%s, reminder %s %s ago: %s", r.TargetLogin, from, utils.PrettyDuration(time.Since(r.CreatedAt)), r.Message)
+	parts := utils.SplitMessage(text, utils.MaxMessageLength, 2)
+	for i, part := range parts {
+		if i < len(parts)-1 {
+			state.Queue.Say(channel, part, queue.PriorityNormal)
+			continue
+		}
+		state.Queue.SayThen(channel, part, queue.PriorityNormal, func() { forget(state, r) })
+	}
+}
+
+// Deletes a claimed reminder that does not need to be delivered again.
+func forget(state *models.State, r models.Reminder) {
+	err := database.DeleteReminder(state.DB, r.ID)
+	if err != nil {
+		log.Printf("Could not delete reminder %d: %s", r.ID, err)
+	}
+}
+
+// Longest delay of a timed reminder.
+const MaxDelay = 365 * 24 * time.Hour
+
+// ParseDelay parses the "in <duration>" at the start of a reminder, like "in 2h30m take out the trash".
+// Durations use Go syntax, and can also be a number of days like "3d".
+// Returns false if the text does not start with a delay. The delay is not checked against [MaxDelay].
+func ParseDelay(text string) (delay time.Duration, message string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || strings.ToLower(fields[0]) != "in" {
+		return 0, text, false
+	}
+	raw := strings.ToLower(fields[1])
+	if days, found := strings.CutSuffix(raw, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, text, false
+		}
+		// Cap the day count before multiplying so it can not overflow, it is still longer than MaxDelay
+		n = min(n, int(MaxDelay/(24*time.Hour))+1)
+		delay = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		delay, err = time.ParseDuration(raw)
+		if err != nil {
+			return 0, text, false
+		}
+	}
+	// Cut the delay off the original text, so the whitespace of the message is kept
+	message = strings.TrimLeftFunc(text, unicode.IsSpace)[len(fields[0]):]
+	message = strings.TrimLeftFunc(message, unicode.IsSpace)[len(fields[1]):]
+	return delay, strings.TrimLeftFunc(message, unicode.IsSpace), true
+}
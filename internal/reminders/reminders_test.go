@@ -0,0 +1,30 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDelay(t *testing.T) {
+	tests := []struct {
+		input   string
+		delay   time.Duration
+		message string
+		ok      bool
+	}{
+		{"in 2h take out the trash", 2 * time.Hour, "take out the trash", true},
+		{"IN 1h30m stream", 90 * time.Minute, "stream", true},
+		{"in 3d birthday", 72 * time.Hour, "birthday", true},
+		{"in 10m", 10 * time.Minute, "", true},
+		{"in 1h  line one\nline  two", time.Hour, "line one\nline  two", true},
+		{"in 9223372036854775807d overflow", MaxDelay + 24*time.Hour, "overflow", true},
+		{"in the morning", 0, "in the morning", false},
+		{"take out the trash", 0, "take out the trash", false},
+	}
+	for _, test := range tests {
+		delay, message, ok := ParseDelay(test.input)
+		if delay != test.delay || message != test.message || ok != test.ok {
+			t.Errorf("Input %q: Expected %s, %q, %t; Got %s, %q, %t", test.input, test.delay, test.message, test.ok, delay, message, ok)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package timers posts timed messages in chats.
+package timers
+
+import (
+	"bot/internal/database"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"bot/internal/queue"
+	"log"
+	"sync"
+	"time"
+)
+
+type timerKey struct {
+	chatID int
+	name   string
+}
+
+var (
+	mu sync.Mutex
+	// Number of messages received per chat since startup
+	messages = make(map[int]int)
+	// Number of messages in the chat when a timer was last posted
+	postedAt = make(map[timerKey]int)
+)
+
+// CountMessage must be called for every chat message, it is used for the minimum messages requirement of timers.
+func CountMessage(chatID int) {
+	mu.Lock()
+	defer mu.Unlock()
+	messages[chatID]++
+}
+
+// Returns the number of messages in a chat since the timer was last posted.
+func messagesSince(timer models.Timer) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return messages[timer.ChatID] - postedAt[timerKey{timer.ChatID, timer.Name}]
+}
+
+func setPosted(timer models.Timer) {
+	mu.Lock()
+	defer mu.Unlock()
+	postedAt[timerKey{timer.ChatID, timer.Name}] = messages[timer.ChatID]
+}
+
+// Run posts due timers every minute. Blocks forever, so it should be started in a goroutine.
+func Run(state *models.State) {
+	for range time.Tick(time.Minute) {
+		timers, err := database.GetDueTimers(state.DB)
+		if err != nil {
+			log.Printf("Could not get due timers: %s", err)
+			continue
+		}
+		// Live status per chat, so every chat is only looked up once per tick
+		live := make(map[int]bool)
+		for _, timer := range timers {
+			if messagesSince(timer) < timer.MinMessages {
+				continue
+			}
+			chat, found, err := database.GetChat(state.DB, timer.ChatID)
+			if err != nil {
+				log.Printf("Could not get chat: %s", err)
+				continue
+			}
+			if !found {
+				continue
+			}
+			if timer.LiveOnly {
+				isLive, checked := live[chat.ChatID]
+				if !checked {
+					_, isLive, err = helix.GetStream(state.Http, chat.ChatName)
+					if err != nil {
+						log.Printf("Could not get stream: %s", err)
+						continue
+					}
+					live[chat.ChatID] = isLive
+				}
+				if !isLive {
+					continue
+				}
+			}
+			if err := database.SetTimerPosted(state.DB, timer); err != nil {
+				log.Printf("Could not update timer: %s", err)
+				continue
+			}
+			setPosted(timer)
+			state.Queue.Say(chat.ChatName, timer.Message, queue.PriorityNormal)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package triggers matches chat messages against keyword and regex triggers.
+// Triggers are compiled once per chat and cached in memory.
+package triggers
+
+import (
+	"bot/internal/database"
+	"bot/internal/models"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type compiled struct {
+	trigger models.Trigger
+	// Set if trigger.Regex is true
+	regex *regexp.Regexp
+	// Lowercased pattern if trigger.CaseInsensitive is true
+	pattern string
+}
+
+func (c compiled) matches(message string) bool {
+	if c.regex != nil {
+		return c.regex.MatchString(message)
+	}
+	if c.trigger.CaseInsensitive {
+		return strings.Contains(strings.ToLower(message), c.pattern)
+	}
+	return strings.Contains(message, c.pattern)
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[int][]compiled)
+	// Last time a trigger fired, by trigger ID
+	lastFired = make(map[int]time.Time)
+)
+
+// Compile validates a trigger, and compiles its pattern.
+func compile(t models.Trigger) (compiled, error) {
+	c := compiled{trigger: t, pattern: t.Pattern}
+	if t.Regex {
+		pattern := t.Pattern
+		if t.CaseInsensitive {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return compiled{}, err
+		}
+		c.regex = regex
+	} else if t.CaseInsensitive {
+		c.pattern = strings.ToLower(t.Pattern)
+	}
+	return c, nil
+}
+
+// Validate returns an error if the pattern of a trigger is invalid.
+func Validate(t models.Trigger) error {
+	_, err := compile(t)
+	return err
+}
+
+// Invalidate removes a chats triggers from the cache. Must be called after triggers are modified.
+func Invalidate(chatID int) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(cache, chatID)
+}
+
+// Returns the compiled triggers of a chat, loading them from the database if they are not cached.
+func load(state *models.State, chatID int) ([]compiled, error) {
+	if triggers, found := cache[chatID]; found {
+		return triggers, nil
+	}
+	triggers, err := database.GetTriggers(state.DB, chatID)
+	if err != nil {
+		return nil, err
+	}
+	compiledTriggers := make([]compiled, 0, len(triggers))
+	for _, t := range triggers {
+		// Triggers are validated before they are added, so this should never fail
+		if c, err := compile(t); err == nil {
+			compiledTriggers = append(compiledTriggers, c)
+		}
+	}
+	cache[chatID] = compiledTriggers
+	return compiledTriggers, nil
+}
+
+// Match returns the first trigger in a chat that matches message, and is not on cooldown.
+// The returned trigger is put on cooldown.
+func Match(state *models.State, chatID int, message string) (models.Trigger, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	triggers, err := load(state, chatID)
+	if err != nil {
+		return models.Trigger{}, false, err
+	}
+	for _, c := range triggers {
+		if !c.matches(message) {
+			continue
+		}
+		cooldown := time.Duration(c.trigger.Cooldown) * time.Millisecond
+		if time.Since(lastFired[c.trigger.ID]) < cooldown {
+			continue
+		}
+		lastFired[c.trigger.ID] = time.Now()
+		return c.trigger, true, nil
+	}
+	return models.Trigger{}, false, nil
+}
@@ -0,0 +1,39 @@
+package triggers
+
+import (
+	"bot/internal/models"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		trigger  models.Trigger
+		message  string
+		expected bool
+	}{
+		{models.Trigger{Pattern: "what game"}, "hey what game is this", true},
+		{models.Trigger{Pattern: "what game"}, "WHAT GAME is this", false},
+		{models.Trigger{Pattern: "what game", CaseInsensitive: true}, "WHAT GAME is this", true},
+		{models.Trigger{Pattern: `^!discord\b`, Regex: true}, "!discord pls", true},
+		{models.Trigger{Pattern: `^!discord\b`, Regex: true}, "!DISCORD", false},
+		{models.Trigger{Pattern: `^!discord\b`, Regex: true, CaseInsensitive: true}, "!DISCORD", true},
+	}
+	for _, test := range tests {
+		c, err := compile(test.trigger)
+		if err != nil {
+			t.Fatalf("Pattern %q: Expected no error; Got %s", test.trigger.Pattern, err)
+		}
+		if actual := c.matches(test.message); actual != test.expected {
+			t.Errorf("Pattern %q, message %q: Expected %t; Got %t", test.trigger.Pattern, test.message, test.expected, actual)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(models.Trigger{Pattern: "(unclosed", Regex: true}); err == nil {
+		t.Errorf("Expected error for invalid regex; Got nil")
+	}
+	if err := Validate(models.Trigger{Pattern: "(unclosed"}); err != nil {
+		t.Errorf("Expected no error for substring pattern; Got %s", err)
+	}
+}
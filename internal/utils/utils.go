@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 	"time"
 	"unicode"
 )
@@ -79,6 +80,52 @@ func SplitStreamOnlineMessage(message string, users []string, length int) (messa
 	return messages
 }
 
+// Maximum length of a chat message in characters.
+const MaxMessageLength = 500
+
+// SplitMessage splits message into messages of at most length characters, splitting between words.
+// Emotes and URLs are words, so they are only cut if they are longer than length on their own.
+// If more than maxParts messages are needed, the last message is truncated and ends with "...".
+func SplitMessage(message string, length int, maxParts int) (messages []string) {
+	var current []rune
+	for _, word := range strings.Fields(message) {
+		runes := []rune(word)
+		if len(current) > 0 && len(current)+1+len(runes) <= length {
+			current = append(append(current, ' '), runes...)
+			continue
+		}
+		if len(current) > 0 {
+			messages = append(messages, string(current))
+		}
+		// Only cut words that don't fit in a message of their own
+		for len(runes) > length {
+			messages = append(messages, string(runes[:length]))
+			runes = runes[length:]
+		}
+		current = runes
+	}
+	if len(current) > 0 {
+		messages = append(messages, string(current))
+	}
+	if len(messages) <= maxParts {
+		return messages
+	}
+
+	messages = messages[:maxParts]
+	last := []rune(messages[maxParts-1])
+	// Remove whole words until the ellipsis fits
+	for len(last)+len(" ...") > length {
+		i := strings.LastIndex(string(last), " ")
+		if i <= 0 {
+			last = last[:length-len(" ...")]
+			break
+		}
+		last = []rune(string(last)[:i])
+	}
+	messages[maxParts-1] = string(last) + " ..."
+	return messages
+}
+
 func CapitalizeFirstCharacter(s string) string {
 	r := []rune(s)
 	r[0] = unicode.ToTitle(r[0])
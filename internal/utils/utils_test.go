@@ -77,3 +77,38 @@ func TestCapitalizeFirstCharacter(t *testing.T) {
 		t.Errorf("Expected %s; Got %s", expected, actual)
 	}
 }
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		length   int
+		maxParts int
+		expected []string
+	}{
+		{"short message", 20, 3, []string{"short message"}},
+		{"one two three four", 9, 3, []string{"one two", "three", "four"}},
+		// Runes, not bytes
+		{"åäö åäö åäö", 7, 3, []string{"åäö åäö", "åäö"}},
+		// URLs are not cut
+		{"see https://example.com/a", 22, 3, []string{"see", "https://example.com/a"}},
+		// Words longer than a message are cut
+		{"abcdefghij", 4, 3, []string{"abcd", "efgh", "ij"}},
+		// Truncated with an ellipsis
+		{"one two three four five six", 9, 2, []string{"one two", "three ..."}},
+		{"one two three four five six", 10, 1, []string{"one ..."}},
+		{"", 10, 1, nil},
+	}
+	for _, test := range tests {
+		actual := SplitMessage(test.message, test.length, test.maxParts)
+		if len(actual) != len(test.expected) {
+			t.Errorf("Input %q: Expected %q; Got %q", test.message, test.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("Input %q: Expected %q; Got %q", test.message, test.expected, actual)
+				break
+			}
+		}
+	}
+}
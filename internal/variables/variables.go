@@ -0,0 +1,248 @@
+// Package variables implements the ${variable} syntax used in static command replies.
+package variables
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Data available to variables when rendering a reply.
+type Data struct {
+	Sender string
+	// Author of the message being replied to, empty if the message is not a reply
+	Parent  string
+	Channel string
+	// Message split into words, with command removed
+	Args []string
+	// Lazily called, since these require API requests
+	Uptime    func() (string, error)
+	Followers func() (int, error)
+	// Adds delta to a counter and returns the new value.
+	// A delta of 0 just reads the counter.
+	Counter func(name string, delta int) (int, error)
+}
+
+var CounterNameRegex = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+func validateCounter(args string) error {
+	if !CounterNameRegex.MatchString(args) {
+		return fmt.Errorf("counter names can only contain a-z, 0-9 and _, like ${count deaths}")
+	}
+	return nil
+}
+
+func renderCounter(delta int) func(args string, data Data) (string, error) {
+	return func(args string, data Data) (string, error) {
+		if data.Counter == nil {
+			return "", fmt.Errorf("counters are not available")
+		}
+		value, err := data.Counter(args, delta)
+		return fmt.Sprint(value), err
+	}
+}
+
+type node struct {
+	// Literal text, if variable is empty
+	text     string
+	variable string
+	args     string
+}
+
+type Template struct {
+	nodes []node
+}
+
+type variable struct {
+	// Validates the arguments of the variable, may be nil
+	validate func(args string) error
+	render   func(args string, data Data) (string, error)
+}
+
+var variables = map[string]variable{
+	"sender": {
+		render: func(_ string, data Data) (string, error) {
+			return data.Sender, nil
+		},
+	},
+	"user": {
+		render: func(_ string, data Data) (string, error) {
+			if len(data.Args) > 0 {
+				return strings.TrimPrefix(data.Args[0], "@"), nil
+			}
+			if data.Parent != "" {
+				return data.Parent, nil
+			}
+			return data.Sender, nil
+		},
+	},
+	"channel": {
+		render: func(_ string, data Data) (string, error) {
+			return data.Channel, nil
+		},
+	},
+	"args": {
+		render: func(_ string, data Data) (string, error) {
+			return strings.Join(data.Args, " "), nil
+		},
+	},
+	"random": {
+		validate: func(args string) error {
+			_, _, err := parseRange(args)
+			return err
+		},
+		render: func(args string, _ Data) (string, error) {
+			low, high, err := parseRange(args)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprint(low + rand.Intn(high-low+1)), nil
+		},
+	},
+	"choice": {
+		validate: func(args string) error {
+			if len(strings.Split(args, "|")) < 2 {
+				return fmt.Errorf("choice needs at least 2 options, like ${choice a|b}")
+			}
+			return nil
+		},
+		render: func(args string, _ Data) (string, error) {
+			options := strings.Split(args, "|")
+			return strings.TrimSpace(options[rand.Intn(len(options))]), nil
+		},
+	},
+	"uptime": {
+		render: func(_ string, data Data) (string, error) {
+			if data.Uptime == nil {
+				return "", fmt.Errorf("uptime is not available")
+			}
+			return data.Uptime()
+		},
+	},
+	"count": {
+		validate: validateCounter,
+		render:   renderCounter(0),
+	},
+	"increment": {
+		validate: validateCounter,
+		render:   renderCounter(1),
+	},
+	"followers": {
+		render: func(_ string, data Data) (string, error) {
+			if data.Followers == nil {
+				return "", fmt.Errorf("followers is not available")
+			}
+			count, err := data.Followers()
+			return fmt.Sprint(count), err
+		},
+	},
+}
+
+// The largest number of values a random range can span.
+const maxRandomRange = 1_000_000_000
+
+// Parses "low-high" into two integers.
+func parseRange(s string) (low int, high int, err error) {
+	lowStr, highStr, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("random needs a range, like ${random 1-100}")
+	}
+	low, err = strconv.Atoi(strings.TrimSpace(lowStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("random range must be whole numbers")
+	}
+	high, err = strconv.Atoi(strings.TrimSpace(highStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("random range must be whole numbers")
+	}
+	if high < low {
+		return 0, 0, fmt.Errorf("random range must go from low to high")
+	}
+	// Checked by subtraction so that extreme bounds can not overflow
+	if high-low < 0 || high-low >= maxRandomRange {
+		return 0, 0, fmt.Errorf("random range can be at most %d numbers wide", maxRandomRange)
+	}
+	return low, high, nil
+}
+
+// Returns true if name is a positional variable, like ${1}.
+func isPositional(name string) bool {
+	i, err := strconv.Atoi(name)
+	return err == nil && i > 0
+}
+
+// Parse parses and validates a reply.
+// The returned error is human readable, and safe to send in chat.
+func Parse(s string) (Template, error) {
+	var t Template
+	for {
+		start := strings.Index(s, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end == -1 {
+			return Template{}, fmt.Errorf("variable is missing a closing }")
+		}
+		end += start
+		if start > 0 {
+			t.nodes = append(t.nodes, node{text: s[:start]})
+		}
+		name, args, _ := strings.Cut(strings.TrimSpace(s[start+2:end]), " ")
+		name = strings.ToLower(name)
+		args = strings.TrimSpace(args)
+		v, found := variables[name]
+		if !found && !isPositional(name) {
+			return Template{}, fmt.Errorf("unknown variable ${%s}", name)
+		}
+		if found && v.validate != nil {
+			if err := v.validate(args); err != nil {
+				return Template{}, err
+			}
+		}
+		t.nodes = append(t.nodes, node{variable: name, args: args})
+		s = s[end+1:]
+	}
+	if s != "" {
+		t.nodes = append(t.nodes, node{text: s})
+	}
+	return t, nil
+}
+
+// Render renders the template. Variables that fail to render are logged, and replaced with "N/A".
+func (t Template) Render(data Data) string {
+	var b strings.Builder
+	for _, n := range t.nodes {
+		if n.variable == "" {
+			b.WriteString(n.text)
+			continue
+		}
+		if isPositional(n.variable) {
+			i, _ := strconv.Atoi(n.variable)
+			if i <= len(data.Args) {
+				b.WriteString(data.Args[i-1])
+			}
+			continue
+		}
+		value, err := variables[n.variable].render(n.args, data)
+		if err != nil {
+			log.Printf("Could not render variable ${%s}: %s", n.variable, err)
+			value = "N/A"
+		}
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+// Expand parses and renders a reply in one go.
+// Replies that can not be parsed are returned as is.
+func Expand(reply string, data Data) string {
+	t, err := Parse(reply)
+	if err != nil {
+		return reply
+	}
+	return t.Render(data)
+}
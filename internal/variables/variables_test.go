@@ -0,0 +1,106 @@
+package variables
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	counters := map[string]int{"deaths": 10}
+	data := Data{
+		Sender:  "linneb",
+		Channel: "forsen",
+		Args:    []string{"@someone", "two"},
+		Followers: func() (int, error) {
+			return 1337, nil
+		},
+		Uptime: func() (string, error) {
+			return "", errors.New("connection refused")
+		},
+		Counter: func(name string, delta int) (int, error) {
+			counters[name] += delta
+			return counters[name], nil
+		},
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"no variables", "no variables"},
+		{"hi ${sender}, welcome to ${channel}", "hi linneb, welcome to forsen"},
+		{"${user} ${2} ${3}", "someone two "},
+		{"${args}", "@someone two"},
+		{"${followers} followers", "1337 followers"},
+		{"live for ${uptime}", "live for N/A"},
+		{"${choice yes|yes}", "yes"},
+		{"${random 5-5}", "5"},
+		{"deaths: ${increment deaths}", "deaths: 11"},
+		{"deaths: ${count deaths}, wins: ${count wins}", "deaths: 11, wins: 0"},
+	}
+	for _, test := range tests {
+		tmpl, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("Input %q: Expected no error; Got %s", test.input, err)
+			continue
+		}
+		actual := tmpl.Render(data)
+		if actual != test.expected {
+			t.Errorf("Input %q: Expected %s; Got %s", test.input, test.expected, actual)
+		}
+	}
+}
+
+func TestUserFallback(t *testing.T) {
+	tmpl, err := Parse("${user}")
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	if actual := tmpl.Render(Data{Sender: "linneb", Parent: "forsen"}); actual != "forsen" {
+		t.Errorf("Expected reply parent forsen; Got %s", actual)
+	}
+	if actual := tmpl.Render(Data{Sender: "linneb"}); actual != "linneb" {
+		t.Errorf("Expected sender linneb; Got %s", actual)
+	}
+}
+
+func TestRandomRange(t *testing.T) {
+	tmpl, err := Parse("${random 1-3}")
+	if err != nil {
+		t.Fatalf("Expected no error; Got %s", err)
+	}
+	for range 100 {
+		i, err := strconv.Atoi(tmpl.Render(Data{}))
+		if err != nil || i < 1 || i > 3 {
+			t.Fatalf("Expected number between 1 and 3; Got %d (%v)", i, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	invalid := []string{
+		"${sender",
+		"${unknown}",
+		"${random}",
+		"${random 10-1}",
+		"${random a-b}",
+		"${random 0-9223372036854775807}",
+		"${random 0-1000000000}",
+		"${choice onlyone}",
+		"${count}",
+		"${increment Deaths!}",
+	}
+	for _, input := range invalid {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Input %q: Expected error; Got nil", input)
+		}
+	}
+}
+
+func TestExpandInvalid(t *testing.T) {
+	input := "costs ${5"
+	actual := Expand(input, Data{})
+	if actual != input {
+		t.Errorf("Expected %s; Got %s", input, actual)
+	}
+}
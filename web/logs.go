@@ -0,0 +1,323 @@
+package web
+
+import (
+	"bot/internal/chatlog"
+	"bot/internal/database"
+	"bot/internal/helix"
+	"bot/internal/models"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number of search results per page
+const searchPageSize = 50
+
+// Number of lines shown before and after a permalinked line
+const permalinkContext = 10
+
+// Registers chat log routes using the URL scheme and formats of justlog, so tools built for it work with the bot.
+//
+// Logs are plain text by default, or the format selected with ?json or ?raw (also ?type=json and ?type=raw).
+// ?reverse returns the newest messages first.
+//
+// Also registers the search pages, which are only accessible with a token from the search command.
+func logRoutes(router *http.ServeMux, state *models.State) error {
+	tmplSearch, err := template.ParseFS(fs, "public/chat_search.tmpl")
+	if err != nil {
+		return err
+	}
+	tmplMessage, err := template.ParseFS(fs, "public/chat_message.tmpl")
+	if err != nil {
+		return err
+	}
+
+	router.HandleFunc("GET /channel/{channel}", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		redirectLogs(w, r, fmt.Sprintf("/channel/%s/%d/%d/%d", r.PathValue("channel"), now.Year(), now.Month(), now.Day()))
+	})
+	router.HandleFunc("GET /channel/{channel}/{year}/{month}/{day}", func(w http.ResponseWriter, r *http.Request) {
+		chat, ok := loggedChat(w, state, r.PathValue("channel"))
+		if !ok {
+			return
+		}
+		from, ok := logDate(w, r.PathValue("year"), r.PathValue("month"), r.PathValue("day"))
+		if !ok {
+			return
+		}
+		entries, err := database.GetChatLogs(state.DB, chat.ChatID, from, from.AddDate(0, 0, 1))
+		if err != nil {
+			log.Printf("Could not get chat logs: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeLogs(w, r, chat.ChatName, entries)
+	})
+	router.HandleFunc("GET /channel/{channel}/user/{user}", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		redirectLogs(w, r, fmt.Sprintf("/channel/%s/user/%s/%d/%d", r.PathValue("channel"), r.PathValue("user"), now.Year(), now.Month()))
+	})
+	router.HandleFunc("GET /channel/{channel}/user/{user}/{year}/{month}", func(w http.ResponseWriter, r *http.Request) {
+		chat, ok := loggedChat(w, state, r.PathValue("channel"))
+		if !ok {
+			return
+		}
+		from, ok := logDate(w, r.PathValue("year"), r.PathValue("month"), "1")
+		if !ok {
+			return
+		}
+		login := strings.ToLower(r.PathValue("user"))
+		userID, found, err := helix.LoginToID(state.Http, login)
+		if err != nil {
+			log.Printf("Could not get user ID: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		optedOut, err := chatlog.OptedOut(state, userID, login)
+		if err != nil {
+			log.Printf("Could not get log opt-outs: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if optedOut {
+			http.Error(w, "User or channel has opted out", http.StatusForbidden)
+			return
+		}
+		entries, err := database.GetUserChatLogs(state.DB, chat.ChatID, userID, login, from, from.AddDate(0, 1, 0))
+		if err != nil {
+			log.Printf("Could not get chat logs: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeLogs(w, r, chat.ChatName, entries)
+	})
+
+	router.HandleFunc("GET /chat/{name}/search", func(w http.ResponseWriter, r *http.Request) {
+		chat, ok := loggedChat(w, state, r.PathValue("name"))
+		if !ok {
+			return
+		}
+		query := r.URL.Query()
+		if !chatlog.VerifyToken(state.Config.WebSecret, query.Get("token"), chat.ChatID) {
+			http.Error(w, "This link is invalid or has expired, use the search command in chat for a new one", http.StatusForbidden)
+			return
+		}
+		type result struct {
+			models.ChatLogEntry
+			Permalink string
+		}
+		data := struct {
+			Chat     string
+			Token    string
+			Query    string
+			User     string
+			From     string
+			To       string
+			Error    string
+			Count    int
+			Results  []result
+			Previous string
+			Next     string
+		}{
+			Chat:  chat.ChatName,
+			Token: query.Get("token"),
+			Query: query.Get("q"),
+			User:  strings.ToLower(strings.TrimPrefix(query.Get("user"), "@")),
+			From:  query.Get("from"),
+			To:    query.Get("to"),
+		}
+		search, errorMessage, err := parseSearch(state, chat, data.Query, data.User, data.From, data.To)
+		if err != nil {
+			log.Printf("Could not parse search: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data.Error = errorMessage
+		if data.Error == "" && (search.Query != "" || search.UserID != 0) {
+			page, _ := strconv.Atoi(query.Get("page"))
+			page = max(page, 0)
+			data.Count, err = database.CountChatLogSearch(state.DB, search)
+			if err != nil {
+				log.Printf("Could not count search results: %s", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			entries, err := database.SearchChatLogs(state.DB, search, searchPageSize, page*searchPageSize)
+			if err != nil {
+				log.Printf("Could not search chat logs: %s", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			for _, e := range entries {
+				permalink := url.Values{"token": {data.Token}, "at": {strconv.FormatInt(e.SentAt.UnixMilli(), 10)}, "id": {e.MessageID}}
+				data.Results = append(data.Results, result{e, fmt.Sprintf("/chat/%s/message?%s", chat.ChatName, permalink.Encode())})
+			}
+			if page > 0 {
+				query.Set("page", strconv.Itoa(page-1))
+				data.Previous = "?" + query.Encode()
+			}
+			if (page+1)*searchPageSize < data.Count {
+				query.Set("page", strconv.Itoa(page+1))
+				data.Next = "?" + query.Encode()
+			}
+		}
+		err = tmplSearch.Execute(w, data)
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+	router.HandleFunc("GET /chat/{name}/message", func(w http.ResponseWriter, r *http.Request) {
+		chat, ok := loggedChat(w, state, r.PathValue("name"))
+		if !ok {
+			return
+		}
+		query := r.URL.Query()
+		if !chatlog.VerifyToken(state.Config.WebSecret, query.Get("token"), chat.ChatID) {
+			http.Error(w, "This link is invalid or has expired, use the search command in chat for a new one", http.StatusForbidden)
+			return
+		}
+		at, err := strconv.ParseInt(query.Get("at"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid time", http.StatusBadRequest)
+			return
+		}
+		entries, err := database.GetChatLogsAround(state.DB, chat.ChatID, time.UnixMilli(at), permalinkContext)
+		if err != nil {
+			log.Printf("Could not get chat logs: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		type line struct {
+			models.ChatLogEntry
+			Selected bool
+		}
+		data := struct {
+			Chat  string
+			Lines []line
+			Back  string
+		}{
+			Chat: chat.ChatName,
+			Back: fmt.Sprintf("/chat/%s/search?%s", chat.ChatName, url.Values{"token": {query.Get("token")}}.Encode()),
+		}
+		for _, e := range entries {
+			data.Lines = append(data.Lines, line{e, e.MessageID != "" && e.MessageID == query.Get("id")})
+		}
+		err = tmplMessage.Execute(w, data)
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+	return nil
+}
+
+// Builds a search from the search form. A non-empty error message is shown to the user.
+func parseSearch(state *models.State, chat models.Chat, query string, user string, from string, to string) (search models.ChatLogSearch, errorMessage string, err error) {
+	search = models.ChatLogSearch{ChatID: chat.ChatID, Query: strings.TrimSpace(query)}
+	if user != "" {
+		userID, found, err := helix.LoginToID(state.Http, user)
+		if err != nil {
+			return search, "", err
+		}
+		if !found {
+			return search, fmt.Sprintf("User %s not found.", user), nil
+		}
+		search.UserID = userID
+	}
+	if from != "" {
+		search.From, err = time.Parse(time.DateOnly, from)
+		if err != nil {
+			return search, "Invalid from date.", nil
+		}
+	}
+	if to != "" {
+		search.To, err = time.Parse(time.DateOnly, to)
+		if err != nil {
+			return search, "Invalid to date.", nil
+		}
+		// Include the whole day
+		search.To = search.To.AddDate(0, 0, 1)
+	}
+	return search, "", nil
+}
+
+// Redirects to path, keeping the query string.
+func redirectLogs(w http.ResponseWriter, r *http.Request, path string) {
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, path, http.StatusFound)
+}
+
+// Gets a chat by name. Writes an error response and returns false if the chat
+// is not joined, or does not have logging enabled.
+func loggedChat(w http.ResponseWriter, state *models.State, name string) (models.Chat, bool) {
+	chat, found, err := database.GetChatByName(state.DB, strings.ToLower(name))
+	if err != nil {
+		log.Printf("Could not get chat: %s", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return chat, false
+	}
+	if !found {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return chat, false
+	}
+	if !chat.LogEnabled {
+		http.Error(w, "User or channel has opted out", http.StatusForbidden)
+		return chat, false
+	}
+	return chat, true
+}
+
+// Parses a UTC date from path values. Writes an error response and returns false if it is invalid.
+func logDate(w http.ResponseWriter, year string, month string, day string) (time.Time, bool) {
+	y, errY := strconv.Atoi(year)
+	m, errM := strconv.Atoi(month)
+	d, errD := strconv.Atoi(day)
+	if errY != nil || errM != nil || errD != nil || m < 1 || m > 12 || d < 1 || d > 31 {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC), true
+}
+
+// Writes entries in the format selected by the query string.
+func writeLogs(w http.ResponseWriter, r *http.Request, channel string, entries []models.ChatLogEntry) {
+	query := r.URL.Query()
+	if query.Has("reverse") {
+		slices.Reverse(entries)
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	switch {
+	case query.Has("json") || query.Get("type") == "json":
+		messages := make([]chatlog.Message, len(entries))
+		for i, e := range entries {
+			messages[i] = chatlog.ToMessage(channel, e)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{"messages": messages})
+		if err != nil {
+			log.Printf("Could not encode chat logs: %s", err)
+		}
+	case query.Has("raw") || query.Get("type") == "raw":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, e := range entries {
+			fmt.Fprintln(w, e.Raw)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, e := range entries {
+			fmt.Fprintln(w, chatlog.FormatLine(channel, e))
+		}
+	}
+}
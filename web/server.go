@@ -2,11 +2,19 @@ package web
 
 import (
 	"bot/internal/commands"
+	"bot/internal/database"
+	"bot/internal/importer"
+	"bot/internal/models"
+	"bot/internal/queue"
 	"embed"
+	"encoding/json"
+	"fmt"
 	"html/template"
+	"io"
 	FS "io/fs"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -43,7 +51,7 @@ func Logging(next http.Handler) http.Handler {
 //go:embed public
 var fs embed.FS
 
-func New(addr string) (*http.ServeMux, error) {
+func New(state *models.State) (*http.ServeMux, error) {
 	tmplCommand, err := template.ParseFS(fs, "public/command.tmpl")
 	if err != nil {
 		return nil, err
@@ -52,6 +60,18 @@ func New(addr string) (*http.ServeMux, error) {
 	if err != nil {
 		return nil, err
 	}
+	tmplImport, err := template.ParseFS(fs, "public/import.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	tmplChatCommands, err := template.ParseFS(fs, "public/chat_commands.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	tmplChatHistory, err := template.ParseFS(fs, "public/chat_history.tmpl")
+	if err != nil {
+		return nil, err
+	}
 
 	router := http.NewServeMux()
 	router.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
@@ -75,6 +95,157 @@ func New(addr string) (*http.ServeMux, error) {
 		}
 	})
 
+	router.HandleFunc("GET /chat/{name}/commands", func(w http.ResponseWriter, r *http.Request) {
+		chat, found, err := database.GetChatByName(state.DB, strings.ToLower(r.PathValue("name")))
+		if err != nil {
+			log.Printf("Could not get chat: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		cmds, err := database.GetCommands(state.DB, chat.ChatID)
+		if err != nil {
+			log.Printf("Could not get commands: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		type row struct {
+			models.Command
+			Cooldown time.Duration
+			Role     string
+		}
+		data := struct {
+			Chat     string
+			Commands []row
+		}{Chat: chat.ChatName}
+		for _, c := range cmds {
+			data.Commands = append(data.Commands, row{
+				Command:  c,
+				Cooldown: time.Duration(c.Cooldown) * time.Millisecond,
+				Role:     commands.PrettyRole(c.MinimumRole),
+			})
+		}
+		err = tmplChatCommands.Execute(w, data)
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+	router.HandleFunc("GET /chat/{name}/commands.json", func(w http.ResponseWriter, r *http.Request) {
+		chat, found, err := database.GetChatByName(state.DB, strings.ToLower(r.PathValue("name")))
+		if err != nil {
+			log.Printf("Could not get chat: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		export, err := commands.ExportCommands(state.DB, chat)
+		if err != nil {
+			log.Printf("Could not export commands: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-commands.json\"", chat.ChatName))
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(export)
+		if err != nil {
+			log.Printf("Could not encode export: %s", err)
+		}
+	})
+
+	router.HandleFunc("GET /chat/{name}/history", func(w http.ResponseWriter, r *http.Request) {
+		chat, found, err := database.GetChatByName(state.DB, strings.ToLower(r.PathValue("name")))
+		if err != nil {
+			log.Printf("Could not get chat: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		history, err := database.GetCommandHistory(state.DB, chat.ChatID, "", 100)
+		if err != nil {
+			log.Printf("Could not get command history: %s", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		err = tmplChatHistory.Execute(w, struct {
+			Chat    string
+			History []models.CommandHistory
+		}{chat.ChatName, history})
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+
+	router.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		depth := state.Queue.Depth()
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]any{
+			"uptime_seconds": int(time.Since(state.StartedAt).Seconds()),
+			"queue": map[string]int{
+				"high":   depth[queue.PriorityHigh],
+				"normal": depth[queue.PriorityNormal],
+				"low":    depth[queue.PriorityLow],
+			},
+		})
+		if err != nil {
+			log.Printf("Could not encode status: %s", err)
+		}
+	})
+
+	router.HandleFunc("GET /import", func(w http.ResponseWriter, r *http.Request) {
+		err := tmplImport.Execute(w, nil)
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+	router.HandleFunc("POST /import", func(w http.ResponseWriter, r *http.Request) {
+		var data struct {
+			Error  string
+			URL    string
+			Result importer.Result
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, importer.MaxUploadSize)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			data.Error = "Could not read uploaded file, make sure it is smaller than 1 MB."
+		} else {
+			defer file.Close()
+			contents, err := io.ReadAll(file)
+			if err == nil {
+				data.Result, err = importer.Parse(contents)
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				data.Error = fmt.Sprintf("Could not parse file: %s", err)
+			} else if token, err := importer.StoreUpload(contents); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				data.Error = "Too many files have been uploaded recently, try again later."
+			} else {
+				data.URL = fmt.Sprintf("%s/import/%s", state.Config.PublicURL, token)
+			}
+		}
+		err = tmplImport.Execute(w, data)
+		if err != nil {
+			log.Printf("Could not execute template: %s", err)
+		}
+	})
+
+	err = logRoutes(router, state)
+	if err != nil {
+		return nil, err
+	}
+
 	staticFS, _ := FS.Sub(fs, "public/static")
 	router.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(staticFS)))
 